@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -21,54 +20,31 @@ var addCmd = &cobra.Command{
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := addProject
-		if projectName == "" && len(args) == 1 {
-			projectName = args[0]
+		projectName, _, err := resolveTarget(addProject, firstArg(args), "")
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
-		needsPrompt := projectName == "" || addEmail == ""
-
-		if needsPrompt {
-			var fields []huh.Field
-
-			if projectName == "" {
-				fields = append(fields, huh.NewInput().
-					Title("Project Name").
-					Value(&projectName).
-					Validate(func(str string) error {
-						if str == "" {
-							return fmt.Errorf("project name is required")
-						}
-						return nil
-					}))
-			}
-
-			if addEmail == "" {
-				fields = append(fields, huh.NewInput().
-					Title("Member Email").
-					Value(&addEmail).
-					Validate(func(str string) error {
-						if str == "" {
-							return fmt.Errorf("email is required")
-						}
-						return nil
-					}))
-			}
-
-			form := huh.NewForm(huh.NewGroup(fields...))
+		if addEmail == "" {
+			form := huh.NewForm(huh.NewGroup(huh.NewInput().
+				Title("Member Email").
+				Value(&addEmail).
+				Validate(func(str string) error {
+					if str == "" {
+						return fmt.Errorf("email is required")
+					}
+					return nil
+				})))
 			if err := form.Run(); err != nil {
 				return Error("cancelled", nil)
 			}
 		}
 
-		if projectName == "" {
-			return Error("project name is required", nil)
-		}
 		if addEmail == "" {
 			return Error("email is required", nil)
 		}
 
-	if err := Application.AddUserToProject(cmd.Context(), addEmail, projectName); err != nil {
+		if err := Application.AddUserToProject(cmd.Context(), addEmail, projectName); err != nil {
 			return Error("failed to add member", err)
 		}
 
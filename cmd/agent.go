@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/envcrypts/envcrypt-cli/internal/agent"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentSocket string
+	agentTTL    time.Duration
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Cache unlocked keys in a local agent",
+	Long: `Run a local agent that keeps your private key and unwrapped project keys in
+memory for --ttl, so commands do not read the keystore (or ask for its
+passphrase) every time. The agent listens on a Unix socket only your user can
+access, and runs until interrupted.
+
+Commands use the agent when ENVCRYPT_AGENT_SOCK points at its socket:
+
+  envcrypt agent &
+  export ENVCRYPT_AGENT_SOCK=$XDG_RUNTIME_DIR/envcrypt/agent.sock`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := agentSocket
+		if path == "" {
+			var err error
+			if path, err = agent.DefaultSocketPath(); err != nil {
+				return Error("failed to find a socket path", err)
+			}
+		}
+
+		listener, err := agent.Listen(path)
+		if err != nil {
+			return Error("failed to start agent", err)
+		}
+		defer os.Remove(path)
+
+		server := agent.NewServer(agentTTL)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		go func() {
+			<-signals
+			server.Lock()
+			listener.Close()
+		}()
+
+		fmt.Printf("%s=%s; export %s;\n", agent.SocketEnv, path, agent.SocketEnv)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render(fmt.Sprintf("Agent listening, keys are kept for %s", agentTTL)))
+
+		if err := server.Serve(listener); err != nil {
+			return Error("agent stopped", err)
+		}
+		return nil
+	},
+}
+
+var agentLockCmd = &cobra.Command{
+	Use:          "lock",
+	Short:        "Make the running agent forget all keys",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := os.Getenv(agent.SocketEnv)
+		if path == "" {
+			return Error(agent.SocketEnv+" is not set", nil)
+		}
+
+		if err := agent.NewClient(path).Lock(); err != nil {
+			return Error("failed to lock agent", err)
+		}
+
+		Success("Agent locked")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentLockCmd)
+
+	agentCmd.Flags().StringVar(&agentSocket, "socket", "", "Socket path (default: $XDG_RUNTIME_DIR/envcrypt/agent.sock)")
+	agentCmd.Flags().DurationVar(&agentTTL, "ttl", time.Hour, "How long keys are kept after they were added")
+}
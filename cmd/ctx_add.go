@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextAddServer string
+	contextAddUse    bool
+)
+
+var contextAddCmd = &cobra.Command{
+	Use:          "add <name>",
+	Short:        "Add a context for a server",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if contextAddServer == "" {
+			return Error("--server is required", nil)
+		}
+
+		if err := config.AddContext(name, contextAddServer); err != nil {
+			return Error("failed to add context", err)
+		}
+		Success(fmt.Sprintf("Added context %s (%s)", name, contextAddServer))
+
+		if contextAddUse {
+			if err := config.UseContext(name); err != nil {
+				return Error("failed to switch context", err)
+			}
+			Success(fmt.Sprintf("Switched to context %s", name))
+		} else {
+			Info(fmt.Sprintf("Run 'envcrypt context use %s' to switch to it", name))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextAddCmd)
+
+	contextAddCmd.Flags().StringVar(&contextAddServer, "server", "", "Server URL for this context")
+	contextAddCmd.Flags().BoolVar(&contextAddUse, "use", false, "Switch to the new context")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var contextListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List contexts",
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		current := config.CurrentContext()
+
+		const nameColWidth = 20
+		const serverColWidth = 40
+
+		fmt.Printf("  %s %s %s\n",
+			headerStyle.Render(padRight("NAME", nameColWidth)),
+			headerStyle.Render(padRight("SERVER", serverColWidth)),
+			headerStyle.Render("USER"),
+		)
+
+		for _, name := range config.ListContexts() {
+			marker := " "
+			if name == current {
+				marker = successStyle.Render("*")
+			}
+
+			user := config.ContextUserEmail(name)
+			if user == "" {
+				user = mutedStyle.Render("(not logged in)")
+			}
+
+			fmt.Printf("%s %s %s %s\n",
+				marker,
+				padRight(name, nameColWidth),
+				padRight(config.ContextBaseURL(name), serverColWidth),
+				user,
+			)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage server contexts",
+	Long: `Manage named contexts. Each context has its own server URL, logged-in
+identity and keyring entries, so you can switch between servers or accounts
+without logging out.
+
+The "default" context uses the top-level settings in config.yaml. Select a
+context per command with --context or ENVCRYPT_CONTEXT.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var contextUseCmd = &cobra.Command{
+	Use:          "use <name>",
+	Short:        "Switch the current context",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if err := config.UseContext(name); err != nil {
+			return Error("failed to switch context", err)
+		}
+
+		Success(fmt.Sprintf("Switched to context %s (%s)", name, config.ContextBaseURL(name)))
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextUseCmd)
+}
@@ -11,9 +11,11 @@ import (
 )
 
 var (
-	diffProject string
-	diffEnv     string
-	showSecrets bool
+	diffProject      string
+	diffEnv          string
+	diffAgainst      string
+	diffFingerprints bool
+	showSecrets      bool
 )
 
 // diffCmd represents the diff command
@@ -24,50 +26,23 @@ var diffCmd = &cobra.Command{
 
 If version numbers are not provided, an interactive prompt will allow you to select the versions to compare.
 
-Use --show-secrets to reveal the actual values in the diff output.`,
+Use --show-secrets to reveal the actual values in the diff output.
+
+With --against, compare two environments of the same project instead. The
+optional version arguments then select the version of --env and of --against
+(default: the heads). Only the key sets are compared unless --show-secrets or
+--fingerprints is given:
+
+  envcrypt diff -p my-app --env staging --against prod`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1. Resolve Project and Env
-		projectName := diffProject
-		envName := diffEnv
-
-		// Prompt for Project if missing
-		if projectName == "" {
-			form := huh.NewForm(
-				huh.NewGroup(
-					huh.NewInput().
-						Title("Project Name").
-						Value(&projectName).
-						Validate(func(str string) error {
-							if str == "" {
-								return fmt.Errorf("project name is required")
-							}
-							return nil
-						}),
-				),
-			)
-			if err := form.Run(); err != nil {
-				return Error("cancelled", nil)
-			}
+		projectName, envName, err := resolveTarget(diffProject, "", diffEnv)
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
-		// Prompt for Env if missing
-		if envName == "" {
-			form := huh.NewForm(
-				huh.NewGroup(
-					huh.NewInput().
-						Title("Environment Name").
-						Value(&envName).
-						Validate(func(str string) error {
-							if str == "" {
-								return fmt.Errorf("environment name is required")
-							}
-							return nil
-						}),
-				),
-			)
-			if err := form.Run(); err != nil {
-				return Error("cancelled", nil)
-			}
+		if diffAgainst != "" {
+			return compareEnvironments(cmd, projectName, envName, diffAgainst, args)
 		}
 
 		// 2. Fetch all versions
@@ -101,13 +76,11 @@ Use --show-secrets to reveal the actual values in the diff output.`,
 			newVer = v2
 		} else {
 			// Interactive selection
+			changes := versionChanges(versions)
 			options := make([]huh.Option[int], len(versions))
 			for i, v := range versions {
 				// Show "Current" for the latest version
-				label := fmt.Sprintf("v%d", v.Version)
-				if i == 0 {
-					label += " (Current)"
-				}
+				label := versionLabel(v, changes[v.Version], i == 0)
 				options[i] = huh.NewOption(label, int(v.Version))
 			}
 
@@ -165,6 +138,48 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	diffCmd.Flags().StringVarP(&diffProject, "project", "p", "", "Project name")
-	diffCmd.Flags().StringVarP(&diffEnv, "env", "e", "", "Environment name")
+	diffCmd.Flags().StringVarP(&diffEnv, "env", "e", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	diffCmd.Flags().StringVar(&diffAgainst, "against", "", "Compare --env against another environment of the project")
+	diffCmd.Flags().BoolVar(&diffFingerprints, "fingerprints", false, "With --against, compare values using masked fingerprints")
 	diffCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show actual secret values in diff output")
 }
+
+// compareEnvironments diffs two environments of a project. Values are only
+// compared when the user asked for secrets or fingerprints.
+func compareEnvironments(cmd *cobra.Command, projectName, envName, againstName string, args []string) error {
+	var versions [2]*int32
+	for i, arg := range args {
+		if i >= len(versions) {
+			return Error("too many version arguments", nil)
+		}
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return Error(fmt.Sprintf("invalid version number %q", arg), err)
+		}
+		v32 := int32(v)
+		versions[i] = &v32
+	}
+
+	left, err := Application.PullEnvVersion(cmd.Context(), projectName, envName, versions[0])
+	if err != nil {
+		return Error(fmt.Sprintf("failed to fetch %s", envName), err)
+	}
+	right, err := Application.PullEnvVersion(cmd.Context(), projectName, againstName, versions[1])
+	if err != nil {
+		return Error(fmt.Sprintf("failed to fetch %s", againstName), err)
+	}
+
+	leftLabel := fmt.Sprintf("%s@v%d", envName, left.Version)
+	rightLabel := fmt.Sprintf("%s@v%d", againstName, right.Version)
+
+	var fingerprint func(string) string
+	if diffFingerprints && !showSecrets {
+		fingerprint, err = cryptoutils.NewValueFingerprinter()
+		if err != nil {
+			return Error("failed to create fingerprints", err)
+		}
+	}
+
+	renderEnvComparison(leftLabel, rightLabel, left.Env, right.Env, showSecrets || diffFingerprints, fingerprint)
+	return nil
+}
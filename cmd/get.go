@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	getProject string
+	getEnvName string
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a single decrypted value",
+	Long: `Print the decrypted value of one variable from the latest version of an
+environment. Only the value is written to stdout, so it can be used in scripts:
+
+  DATABASE_URL=$(envcrypt get --project my-app --env prod DATABASE_URL)`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName, envName, err := resolveTarget(getProject, "", getEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		envMap, err := Application.PullEnv(cmd.Context(), projectName, envName)
+		if err != nil {
+			return Error("failed to pull environment variables", err)
+		}
+
+		value, ok := envMap[args[0]]
+		if !ok {
+			return Error(fmt.Sprintf("%s is not set in %s/%s", args[0], projectName, envName), nil)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+
+	getCmd.Flags().StringVar(&getProject, "project", "", "Project name")
+	getCmd.Flags().StringVar(&getEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+}
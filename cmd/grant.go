@@ -15,13 +15,9 @@ var grantCmd = &cobra.Command{
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := grantProject
-		if projectName == "" && len(args) == 1 {
-			projectName = args[0]
-		}
-
-		if projectName == "" {
-			return Error("project name is required", nil)
+		projectName, _, err := resolveTarget(grantProject, firstArg(args), "")
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
 		if err := Application.GiveAccess(cmd.Context(), projectName, grantEmail); err != nil {
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
 )
 
 func mapEnvReadError(path string, err error) error {
@@ -25,6 +27,13 @@ func resolveEnvFile(flagPath string) (string, error) {
 		return "", fmt.Errorf("env file %q does not exist", flagPath)
 	}
 
+	if path := projectEnvFile(); path != "" {
+		if fileExists(path) {
+			return path, nil
+		}
+		return "", fmt.Errorf("env file %q from %s does not exist", path, config.ProjectFileName)
+	}
+
 	if fileExists(".env") {
 		return ".env", nil
 	}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var keysExportOutput string
+
+var keysExportPublicCmd = &cobra.Command{
+	Use:   "export-public",
+	Short: "Print your public keys in a shareable form",
+	Long: `Print your public keys, one per line, in the form
+
+  envcrypt-x25519 <base64 key> <email>
+  envcrypt-ed25519 <base64 key> <email>
+
+The output contains no secrets and can be pasted into chat or a wiki.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := Application.LocalKeys()
+		if err != nil {
+			return Error("failed to read your key", err)
+		}
+
+		var out strings.Builder
+		fmt.Fprintf(&out, "envcrypt-x25519 %s %s\n", base64.StdEncoding.EncodeToString(keys.PublicKey), keys.Email)
+		fmt.Fprintf(&out, "envcrypt-ed25519 %s %s\n", base64.StdEncoding.EncodeToString(keys.SigningPublicKey), keys.Email)
+
+		if keysExportOutput == "" {
+			fmt.Print(out.String())
+			return nil
+		}
+
+		if _, err := os.Stat(keysExportOutput); err == nil {
+			if !ConfirmOverwrite(keysExportOutput) {
+				return nil
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return Error("failed to check output file", err)
+		}
+
+		if err := os.WriteFile(keysExportOutput, []byte(out.String()), 0644); err != nil {
+			return Error("failed to write public keys", err)
+		}
+
+		Success(fmt.Sprintf("Public keys of %s written to %s", keys.Email, keysExportOutput))
+		return nil
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysExportPublicCmd)
+
+	keysExportPublicCmd.Flags().StringVarP(&keysExportOutput, "output", "o", "", "Write to a file instead of stdout")
+}
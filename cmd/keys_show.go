@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/base64"
+
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/spf13/cobra"
+)
+
+var keysShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Show your public keys and their fingerprints",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := Application.LocalKeys()
+		if err != nil {
+			return Error("failed to read your key", err)
+		}
+
+		Info("User:        " + keys.Email)
+		Spacer()
+		Info("Encryption key (X25519)")
+		Info("  Fingerprint: " + cryptoutils.Fingerprint(keys.PublicKey))
+		Info("  Public key:  " + base64.StdEncoding.EncodeToString(keys.PublicKey))
+		Spacer()
+		Info("Signing key (Ed25519)")
+		Info("  Fingerprint: " + cryptoutils.Fingerprint(keys.SigningPublicKey))
+		Info("  Public key:  " + base64.StdEncoding.EncodeToString(keys.SigningPublicKey))
+		return nil
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysShowCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logProject string
+	logEnvName string
+	logLimit   int
+	logOneline bool
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log [project]",
+	Short: "Show the version history of an environment",
+	Long: `List the versions of an environment, newest first, with the type of change,
+author, time, message and the number of keys added, removed and modified.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName, envName, err := resolveTarget(logProject, firstArg(args), logEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		versions, err := Application.PullAllEnv(cmd.Context(), projectName, envName)
+		if err != nil {
+			return Error("failed to fetch environment versions", err)
+		}
+
+		if len(versions) == 0 {
+			fmt.Println(mutedStyle.Render("No versions found."))
+			return nil
+		}
+
+		changes := versionChanges(versions)
+
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].Version > versions[j].Version
+		})
+		if logLimit > 0 && len(versions) > logLimit {
+			versions = versions[:logLimit]
+		}
+
+		for i, v := range versions {
+			diff := changes[v.Version]
+
+			if logOneline {
+				fmt.Println(versionLabel(v, diff, i == 0))
+				continue
+			}
+
+			title := fmt.Sprintf("v%d", v.Version)
+			if i == 0 {
+				title += " (Current)"
+			}
+			if v.Metadata.Type != "" {
+				title += " " + mutedStyle.Render(v.Metadata.Type)
+			}
+			fmt.Println(warnStyle.Render(title))
+
+			if v.Metadata.Author != "" {
+				fmt.Printf("%s %s\n", headerStyle.Render("Author: "), v.Metadata.Author)
+			}
+			if t := formatVersionTime(v); t != "" {
+				fmt.Printf("%s %s\n", headerStyle.Render("Date:   "), t)
+			}
+			fmt.Printf("%s %s\n", headerStyle.Render("Changes:"), formatChangeCounts(diff))
+
+			if v.Metadata.Message != "" {
+				Spacer()
+				fmt.Printf("    %s\n", v.Metadata.Message)
+			}
+			Spacer()
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+
+	logCmd.Flags().StringVar(&logProject, "project", "", "Project name")
+	logCmd.Flags().StringVar(&logEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 0, "Only show the newest n versions")
+	logCmd.Flags().BoolVar(&logOneline, "oneline", false, "Show one line per version")
+}
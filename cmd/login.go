@@ -62,11 +62,17 @@ to encrypted environment variables without exposing plaintext secrets.`,
 			return Error("cancelled", nil)
 		}
 
-		if err := Application.Login(cmd.Context(), email, password); err != nil {
+		result, err := Application.Login(cmd.Context(), email, password)
+		if err != nil {
 			return Error("login failed", err)
 		}
 
 		Success("Login successful")
+		if result.KeyUpgraded {
+			Info("Upgraded the encryption of your private key")
+		} else if result.UpgradeErr != nil {
+			Warn(fmt.Sprintf("Could not upgrade the encryption of your private key: %v", result.UpgradeErr))
+		}
 		return nil
 	},
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateProject string
+	migrateAccount bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [project]",
+	Short: "Upgrade stored secrets to the current encryption format",
+	Long: `Re-encrypt stored blobs in the current encryption format. Older formats
+stay readable, but only the current one is bound to where each blob belongs
+and can switch cipher suites.
+
+The local keystore file is always upgraded. With a project, its key is
+rewrapped for every member and service role and every version is
+re-encrypted under the same key; this needs admin rights and can be repeated
+if interrupted. With --account, the private key stored on the server is
+re-encrypted too, which asks for your password.
+
+New blobs use the cipher suite set as cipher_suite in config.yaml:
+aes-256-gcm (default) or xchacha20-poly1305.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Migrating a project needs admin rights, so it only happens for a
+		// project named on the command line, never one from .envcrypt.yaml.
+		var projectName string
+		if migrateProject != "" || len(args) > 0 {
+			var err error
+			projectName, _, err = resolveTarget(migrateProject, firstArg(args), "")
+			if err != nil {
+				return Error("failed to resolve project", err)
+			}
+		}
+
+		upgraded, err := Application.MigrateKeystore()
+		if err != nil {
+			return Error("failed to upgrade the keystore", err)
+		}
+		if upgraded > 0 {
+			Success(fmt.Sprintf("Upgraded %d keystore entries", upgraded))
+		} else {
+			Info("The keystore is up to date")
+		}
+
+		if migrateAccount {
+			password, err := readPassword("Password: ")
+			if err != nil {
+				return Error("Failed to read password", err)
+			}
+
+			uploaded, err := Application.MigrateAccountKey(cmd.Context(), password)
+			if err != nil {
+				return Error("failed to upgrade your private key", err)
+			}
+			if uploaded {
+				Success("Upgraded the encryption of your private key")
+			} else {
+				Info("Your private key is up to date")
+			}
+		}
+
+		if projectName == "" {
+			return nil
+		}
+
+		progress := func(p app.RotateKeyProgress) {
+			status := "re-encrypted"
+			if p.Skipped {
+				status = "up to date"
+			}
+			fmt.Println(mutedStyle.Render(fmt.Sprintf("  [%d/%d] %s v%d %s", p.Done, p.Total, p.EnvName, p.Version, status)))
+		}
+
+		result, err := Application.MigrateProject(cmd.Context(), projectName, progress)
+		if err != nil {
+			return Error("failed to migrate project", fmt.Errorf("%w\nRun the command again to resume", err))
+		}
+
+		Success(fmt.Sprintf("Rewrapped the key of %q for %d member(s) and %d service role delegation(s)", projectName, result.Members, result.ServiceRoles))
+		Success(fmt.Sprintf("Migrated %q (%d version(s) re-encrypted, %d already up to date)", projectName, result.Reencrypted, result.Skipped))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVar(&migrateProject, "project", "", "Project to migrate")
+	migrateCmd.Flags().BoolVar(&migrateAccount, "account", false, "Also upgrade the private key stored on the server")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var passwdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change your password",
+	Long: `Change your account password. Your private key is re-encrypted with the
+new password; the keypair stays the same, so access to projects is kept.`,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldPassword, err := readPassword("Current password: ")
+		if err != nil {
+			return Error("Failed to read password", err)
+		}
+
+		newPassword, err := readPassword("New password: ")
+		if err != nil {
+			return Error("Failed to read password", err)
+		}
+		if newPassword == "" {
+			return Error("new password is required", nil)
+		}
+		if newPassword == oldPassword {
+			return Error("new password must differ from the current one", nil)
+		}
+
+		confirm, err := readPassword("Repeat new password: ")
+		if err != nil {
+			return Error("Failed to read password", err)
+		}
+		if confirm != newPassword {
+			return Error("passwords do not match", nil)
+		}
+
+		if err := Application.ChangePassword(cmd.Context(), oldPassword, newPassword); err != nil {
+			return Error("failed to change password", err)
+		}
+
+		Success("Password changed")
+		return nil
+	},
+}
+
+func readPassword(prompt string) (string, error) {
+	// stderr, so the prompt never ends up in piped output
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+func init() {
+	rootCmd.AddCommand(passwdCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotateKeyProject string
+	rotateKeyForce   bool
+)
+
+var projectRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key [project]",
+	Short: "Replace the project master key",
+	Long: `Generate a new project master key, hand it to every active member and
+delegated service role, and re-encrypt every version of every environment
+with it. Revoked members keep only the old key, which no longer decrypts
+anything.
+
+Members cannot read versions that have not been re-encrypted yet, so let the
+command finish. If it is interrupted, run it again to resume.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName, _, err := resolveTarget(rotateKeyProject, firstArg(args), "")
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		if !rotateKeyForce {
+			ok := ConfirmDangerousAction(
+				fmt.Sprintf("This will replace the master key of project %q and re-encrypt all of its versions.", projectName),
+				projectName,
+			)
+			if !ok {
+				return nil
+			}
+		}
+
+		progress := func(p app.RotateKeyProgress) {
+			status := "re-encrypted"
+			if p.Skipped {
+				status = "already rotated"
+			}
+			fmt.Println(mutedStyle.Render(fmt.Sprintf("  [%d/%d] %s v%d %s", p.Done, p.Total, p.EnvName, p.Version, status)))
+		}
+
+		result, err := Application.RotateProjectKey(cmd.Context(), projectName, progress)
+		if err != nil {
+			return Error("failed to rotate project key", fmt.Errorf("%w\nRun the command again to resume", err))
+		}
+
+		if result.Resumed {
+			Info("Resumed an interrupted rotation")
+		}
+		if result.Members > 0 || result.ServiceRoles > 0 {
+			Success(fmt.Sprintf("New key shared with %d member(s) and %d service role delegation(s)", result.Members, result.ServiceRoles))
+		}
+		Success(fmt.Sprintf("Rotated the master key of %q (%d version(s) re-encrypted, %d already done)", projectName, result.Reencrypted, result.Skipped))
+		return nil
+	},
+}
+
+func init() {
+	projectCmd.AddCommand(projectRotateKeyCmd)
+
+	projectRotateKeyCmd.Flags().StringVar(&rotateKeyProject, "project", "", "Project name")
+	projectRotateKeyCmd.Flags().BoolVar(&rotateKeyForce, "force", false, "Rotate without confirmation")
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/envcrypts/envcrypt-cli/internal/formats"
 	"github.com/spf13/cobra"
 )
 
@@ -13,63 +13,80 @@ var (
 	pullEnvName string
 	pullEnvFile string
 	pullYes     bool
+	pullFormat  string
+	pullStdout  bool
 )
 
 var pullCmd = &cobra.Command{
-	Use:          "pull [project]",
-	Short:        "Download and decrypt environment variables",
-	Long:         "Download environment variables from a project and write them to a .env file.",
+	Use:   "pull [project]",
+	Short: "Download and decrypt environment variables",
+	Long: `Download environment variables from a project and write them to a .env file.
+
+Use --format to write dotenv, json, yaml, shell (export lines), docker
+(--env-file), systemd (EnvironmentFile) or k8s (a Secret manifest), and
+--stdout to print the result instead of writing a file.`,
 	Args:         cobra.MaximumNArgs(1),
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := pullProject
-		if projectName == "" && len(args) == 1 {
-			projectName = args[0]
-		}
-		if projectName == "" {
-			return Error("project name is required", nil)
+		projectName, envName, err := resolveTarget(pullProject, firstArg(args), pullEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
-		envName := pullEnvName
-		if envName == "" {
-			envName = "dev"
+		format, err := formats.ParseFormat(pullFormat, formats.OutputFormats)
+		if err != nil {
+			return Error("invalid output format", err)
 		}
 
 		envPath := pullEnvFile
+		if envPath == "" && format == formats.Dotenv {
+			envPath = projectEnvFile()
+		}
 		if envPath == "" {
-			envPath = ".env"
+			envPath = format.DefaultFileName()
 		}
 
-		Info("Project: " + projectName)
-		Info("Environment: " + envName)
+		if !pullStdout {
+			Info("Project: " + projectName)
+			Info("Environment: " + envName)
 
-		if fileExists(envPath) && !pullYes {
-			if !ConfirmOverwrite(envPath) {
-				return nil
+			if fileExists(envPath) && !pullYes {
+				if !ConfirmOverwrite(envPath) {
+					return nil
+				}
 			}
 		}
 
-		envMap, err := Application.PullEnv(
+		env, err := Application.PullEnvVersion(
 			cmd.Context(),
 			projectName,
 			envName,
+			nil,
 		)
 		if err != nil {
 			return Error("failed to pull environment variables", err)
 		}
+		envMap := env.Env
 
-		if len(envMap) == 0 {
-			Info(fmt.Sprintf("No environment variables found for %s. Creating empty .env file.", envName))
+		envBytes, err := formats.Encode(format, envMap, formats.EncodeOptions{
+			SecretName: projectName + "-" + envName,
+		})
+		if err != nil {
+			return Error("failed to encode env file", err)
 		}
 
-		printEnvSummary(envMap)
+		if pullStdout {
+			_, err := cmd.OutOrStdout().Write(envBytes)
+			return err
+		}
 
-		envBytes, err := cryptoutils.EncodeEnv(envMap)
-		if err != nil {
-			return Error("failed to encode env file", err)
+		if len(envMap) == 0 {
+			Info(fmt.Sprintf("No environment variables found for %s. Creating empty %s file.", envName, envPath))
 		}
 
+		printEnvSummary(envMap)
+
 		if err := os.WriteFile(envPath, envBytes, 0600); err != nil {
 			return Error(
 				"failed to write env file",
@@ -77,12 +94,21 @@ var pullCmd = &cobra.Command{
 			)
 		}
 
+		// The base version is what push compares against, and push reads
+		// the dotenv file, so other formats must not move it.
+		if format == formats.Dotenv {
+			if err := Application.SetBaseVersion(projectName, envName, env.Version); err != nil {
+				Warn(fmt.Sprintf("could not record pulled version: %v", err))
+			}
+		}
+
 		Success(
 			fmt.Sprintf(
-				"Pulled environment variables to %s/%s (%s)",
+				"Pulled environment variables to %s/%s (%s, v%d)",
 				projectName,
 				envName,
 				envPath,
+				env.Version,
 			),
 		)
 
@@ -94,7 +120,9 @@ func init() {
 	rootCmd.AddCommand(pullCmd)
 
 	pullCmd.Flags().StringVar(&pullProject, "project", "", "Project name")
-	pullCmd.Flags().StringVar(&pullEnvName, "env", "dev", "Environment name (dev, staging, prod)")
-	pullCmd.Flags().StringVarP(&pullEnvFile, "env-file", "e", "", "Path to write .env file (default: ./.env)")
+	pullCmd.Flags().StringVar(&pullEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	pullCmd.Flags().StringVarP(&pullEnvFile, "env-file", "e", "", "Path to write the output file (default depends on --format, ./.env for dotenv)")
 	pullCmd.Flags().BoolVarP(&pullYes, "yes", "y", false, "Skip confirmation when overwriting .env file")
+	pullCmd.Flags().StringVarP(&pullFormat, "format", "f", string(formats.Dotenv), "Output format: dotenv, json, yaml, shell, docker, systemd, k8s")
+	pullCmd.Flags().BoolVar(&pullStdout, "stdout", false, "Write to stdout instead of a file")
 }
@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
-	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/envcrypts/envcrypt-cli/internal/app"
+	"github.com/envcrypts/envcrypt-cli/internal/formats"
 	"github.com/spf13/cobra"
 )
 
@@ -12,27 +14,27 @@ var (
 	pushProject string
 	pushEnvName string
 	pushEnvFile string
+	pushForce   bool
+	pushMessage string
+
+	pushInputFormat string
 )
 
 var pushCmd = &cobra.Command{
-	Use:          "push [project]",
-	Short:        "Encrypt and upload environment variables",
-	Long:         "Encrypt variables from a .env file and upload them to a project environment.",
+	Use:   "push [project]",
+	Short: "Encrypt and upload environment variables",
+	Long: `Encrypt variables from a .env file and upload them to a project environment.
+
+JSON, YAML and shell (export lines, heroku config --shell) files are also
+accepted. The format is detected from the file extension or set with
+--input-format. Nested JSON and YAML keys are flattened with "_".`,
 	Args:         cobra.MaximumNArgs(1),
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := pushProject
-		if projectName == "" && len(args) == 1 {
-			projectName = args[0]
-		}
-		if projectName == "" {
-			return Error("project name is required", nil)
-		}
-
-		envName := pushEnvName
-		if envName == "" {
-			envName = "dev"
+		projectName, envName, err := resolveTarget(pushProject, firstArg(args), pushEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
 		envPath, err := resolveEnvFile(pushEnvFile)
@@ -48,9 +50,17 @@ var pushCmd = &cobra.Command{
 			return Error("failed to read env file", mapEnvReadError(envPath, err))
 		}
 
-		envMap, err := cryptoutils.ParseEnv(fileData)
+		format := formats.DetectFormat(envPath)
+		if pushInputFormat != "" {
+			format, err = formats.ParseFormat(pushInputFormat, formats.InputFormats)
+			if err != nil {
+				return Error("invalid input format", err)
+			}
+		}
+
+		envMap, err := formats.Decode(format, fileData)
 		if err != nil {
-			return Error("failed to parse env file", mapEnvReadError(envPath, err))
+			return Error(fmt.Sprintf("failed to parse %s as %s", envPath, format), err)
 		}
 		if len(envMap) == 0 {
 			return Error(
@@ -60,20 +70,35 @@ var pushCmd = &cobra.Command{
 		}
 		printEnvSummary(envMap)
 
-		if err := Application.PushEnv(
+		result, err := Application.PushEnv(
 			cmd.Context(),
 			projectName,
 			envName,
 			envMap,
-		); err != nil {
+			app.PushOptions{Force: pushForce, Message: pushMessage},
+		)
+		if err != nil {
+			var conflict *app.PushConflictError
+			if errors.As(err, &conflict) {
+				renderPushConflict(conflict)
+				return Error(
+					"push rejected: the remote environment changed since your version",
+					errors.New("pull and reapply your changes, or push again with --force to overwrite"),
+				)
+			}
 			return Error("failed to upload environment variables", err)
 		}
 
+		if result.Merged {
+			Info("Merged non-conflicting remote changes; run pull to update your local file")
+		}
+
 		Success(
 			fmt.Sprintf(
-				"Uploaded environment variables to %s/%s",
+				"Uploaded environment variables to %s/%s (v%d)",
 				projectName,
 				envName,
+				result.Version,
 			),
 		)
 
@@ -85,6 +110,9 @@ func init() {
 	rootCmd.AddCommand(pushCmd)
 
 	pushCmd.Flags().StringVar(&pushProject, "project", "", "Project name")
-	pushCmd.Flags().StringVar(&pushEnvName, "env", "dev", "Environment name (dev, staging, prod)")
+	pushCmd.Flags().StringVar(&pushEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
 	pushCmd.Flags().StringVarP(&pushEnvFile, "env-file", "e", "", "Path to .env file (default: ./.env)")
+	pushCmd.Flags().StringVar(&pushInputFormat, "input-format", "", "Input format: dotenv, json, yaml, shell (default: detected from the file extension)")
+	pushCmd.Flags().StringVarP(&pushMessage, "message", "m", "", "Describe the change, like a commit message")
+	pushCmd.Flags().BoolVar(&pushForce, "force", false, "Overwrite the remote environment even if it changed since your last pull")
 }
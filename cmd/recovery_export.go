@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var recoveryExportOutput string
+
+var recoveryExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write a recovery kit for your private key",
+	Long: `Write your private key to a file encrypted with a recovery passphrase.
+Use a passphrase that differs from your password, and store the file and the
+passphrase offline, apart from each other.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := os.Stat(recoveryExportOutput); err == nil {
+			if !ConfirmOverwrite(recoveryExportOutput) {
+				return nil
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return Error("failed to check output file", err)
+		}
+
+		passphrase, err := readPassword("Recovery passphrase: ")
+		if err != nil {
+			return Error("Failed to read passphrase", err)
+		}
+		if passphrase == "" {
+			return Error("recovery passphrase is required", nil)
+		}
+		confirm, err := readPassword("Repeat recovery passphrase: ")
+		if err != nil {
+			return Error("Failed to read passphrase", err)
+		}
+		if confirm != passphrase {
+			return Error("passphrases do not match", nil)
+		}
+
+		kit, err := Application.ExportRecoveryKit(passphrase)
+		if err != nil {
+			return Error("failed to create recovery kit", err)
+		}
+
+		data, err := json.MarshalIndent(kit, "", "  ")
+		if err != nil {
+			return Error("failed to encode recovery kit", err)
+		}
+
+		if err := os.WriteFile(recoveryExportOutput, append(data, '\n'), 0600); err != nil {
+			return Error("failed to write recovery kit", err)
+		}
+
+		Success(fmt.Sprintf("Recovery kit for %s written to %s", kit.Email, recoveryExportOutput))
+		Info("Store it offline; anyone with the file and the passphrase can read your projects")
+		return nil
+	},
+}
+
+func init() {
+	recoveryCmd.AddCommand(recoveryExportCmd)
+
+	recoveryExportCmd.Flags().StringVarP(&recoveryExportOutput, "output", "o", "envcrypt-recovery.json", "Path of the recovery kit")
+}
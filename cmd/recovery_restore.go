@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var recoveryRestoreCmd = &cobra.Command{
+	Use:   "restore <kit-file>",
+	Short: "Regain access to your account from a recovery kit",
+	Long: `Decrypt a recovery kit, set a new account password and store the private
+key in the keyring. Your keypair is unchanged, so access to projects is kept.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return Error("failed to read recovery kit", err)
+		}
+
+		var kit config.RecoveryKit
+		if err := json.Unmarshal(data, &kit); err != nil {
+			return Error("failed to parse recovery kit", err)
+		}
+
+		Info(fmt.Sprintf("Recovery kit for %s (created %s)", kit.Email, kit.CreatedAt.Local().Format("2006-01-02")))
+
+		passphrase, err := readPassword("Recovery passphrase: ")
+		if err != nil {
+			return Error("Failed to read passphrase", err)
+		}
+
+		newPassword, err := readPassword("New password: ")
+		if err != nil {
+			return Error("Failed to read password", err)
+		}
+		if newPassword == "" {
+			return Error("new password is required", nil)
+		}
+		confirm, err := readPassword("Repeat new password: ")
+		if err != nil {
+			return Error("Failed to read password", err)
+		}
+		if confirm != newPassword {
+			return Error("passwords do not match", nil)
+		}
+
+		if err := Application.RestoreFromRecoveryKit(cmd.Context(), &kit, passphrase, newPassword); err != nil {
+			return Error("failed to restore account", err)
+		}
+
+		Success(fmt.Sprintf("Restored %s; log in with your new password from now on", kit.Email))
+		return nil
+	},
+}
+
+func init() {
+	recoveryCmd.AddCommand(recoveryRestoreCmd)
+}
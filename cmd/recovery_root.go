@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// recoveryCmd represents the recovery command
+var recoveryCmd = &cobra.Command{
+	Use:   "recovery",
+	Short: "Back up and restore your private key",
+	Long: `Export an offline recovery kit for your private key, and use it to regain
+access to your account and projects if you forget your password.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recoveryCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"golang.org/x/term"
+)
+
+const defaultEnvName = "dev"
+
+// loadProjectFile finds .envcrypt.yaml from the working directory upwards.
+func loadProjectFile() (*config.ProjectFile, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return config.FindProjectFile(wd)
+}
+
+// resolveTarget decides which project and environment a command acts on.
+//
+// The project comes from --project, then the positional argument, then
+// .envcrypt.yaml, then an interactive prompt. The environment comes from
+// --env, then the branch mapping in .envcrypt.yaml, then its default
+// environment, then "dev".
+func resolveTarget(flagProject, argProject, flagEnv string) (string, string, error) {
+	pf, err := loadProjectFile()
+	if err != nil {
+		return "", "", err
+	}
+
+	projectName := flagProject
+	if projectName == "" {
+		projectName = argProject
+	}
+	if projectName == "" && pf != nil {
+		projectName = pf.Project
+	}
+	if projectName == "" {
+		projectName, err = promptProjectName()
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	envName := flagEnv
+	if envName == "" && pf != nil {
+		if len(pf.Branches) > 0 {
+			if branch, err := getCurrentBranch(); err == nil {
+				envName = pf.EnvForBranch(branch)
+			}
+		}
+		if envName == "" {
+			envName = pf.Env
+		}
+	}
+	if envName == "" {
+		envName = defaultEnvName
+	}
+
+	return projectName, envName, nil
+}
+
+func promptProjectName() (string, error) {
+	missing := fmt.Errorf("project name is required (use --project or add a %s file)", config.ProjectFileName)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", missing
+	}
+
+	var projectName string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Project Name").
+				Value(&projectName).
+				Validate(func(str string) error {
+					if str == "" {
+						return errors.New("project name is required")
+					}
+					return nil
+				}),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return "", errors.New("cancelled")
+	}
+
+	return projectName, nil
+}
+
+// projectEnvFile returns the env_file declared in .envcrypt.yaml, if any.
+func projectEnvFile() string {
+	pf, err := loadProjectFile()
+	if err != nil || pf == nil {
+		return ""
+	}
+	return pf.EnvFilePath()
+}
+
+func firstArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
@@ -1,27 +1,37 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/envcrypts/envcrypt-cli/internal/app"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
 
 var (
 	revokeProject string
 	revokeEmail   string
+	revokeRotate  bool
 )
 
 var revokeCmd = &cobra.Command{
-	Use:          "revoke [project]",
-	Short:        "Revoke a user's access to a project",
-	Long:         "Revoke a user's access to a project without removing the member.",
+	Use:   "revoke [project]",
+	Short: "Revoke a user's access to a project",
+	Long: `Revoke a user's access to a project without removing the member.
+
+The revoked user may have kept the project key, so revoking offers to rotate
+it right away (see 'envcrypt project rotate-key'). Use --rotate to rotate
+without asking. Afterwards the environments whose secrets the user could
+have read are listed; those secrets should be changed at their source.`,
 	Args:         cobra.MaximumNArgs(1),
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := revokeProject
-		if projectName == "" && len(args) == 1 {
-			projectName = args[0]
-		}
-
-		if projectName == "" {
-			return Error("project name is required", nil)
+		projectName, _, err := resolveTarget(revokeProject, firstArg(args), "")
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
 		if err := Application.RevokeAccess(cmd.Context(), projectName, revokeEmail); err != nil {
@@ -29,14 +39,53 @@ var revokeCmd = &cobra.Command{
 		}
 
 		Success("Revoked access for " + revokeEmail + " on project " + projectName)
+
+		rotate := revokeRotate
+		if !rotate && term.IsTerminal(int(os.Stdin.Fd())) {
+			Spacer()
+			rotate = Confirm(fmt.Sprintf("%s may still hold the project key. Rotate it now?", revokeEmail))
+		}
+
+		if rotate {
+			Spacer()
+			result, err := Application.RotateProjectKey(cmd.Context(), projectName, nil)
+			if err != nil {
+				return Error("failed to rotate project key", fmt.Errorf("%w\nRun 'envcrypt project rotate-key %s' to resume", err, projectName))
+			}
+			Success(fmt.Sprintf("Rotated the master key of %q (%d version(s) re-encrypted)", projectName, result.Reencrypted))
+		} else {
+			Warn(fmt.Sprintf("The project key was not rotated; run 'envcrypt project rotate-key %s' later", projectName))
+		}
+
+		exposed, err := Application.ExposedEnvs(cmd.Context(), projectName)
+		if err != nil {
+			return Error("failed to list exposed environments", err)
+		}
+		renderExposedEnvs(revokeEmail, exposed)
+
 		return nil
 	},
 }
 
+// renderExposedEnvs lists the secrets a revoked user could have read. A key
+// rotation does not help with those: they have to be changed at the source.
+func renderExposedEnvs(email string, exposed []app.ExposedEnv) {
+	if len(exposed) == 0 {
+		return
+	}
+
+	Spacer()
+	Warn(fmt.Sprintf("%s could read these secrets; change them at the source:", email))
+	for _, env := range exposed {
+		fmt.Printf("  %s %s\n", headerStyle.Render(fmt.Sprintf("%s@v%d", env.EnvName, env.Version)), strings.Join(env.Keys, ", "))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(revokeCmd)
 
 	revokeCmd.Flags().StringVar(&revokeProject, "project", "", "Project name")
 	revokeCmd.Flags().StringVar(&revokeEmail, "email", "", "Email address of the user")
+	revokeCmd.Flags().BoolVar(&revokeRotate, "rotate", false, "Rotate the project key without asking")
 	revokeCmd.MarkFlagRequired("email")
 }
@@ -30,47 +30,9 @@ This command will create a new version that is an exact copy of the specified pr
 You will see a diff of the changes before confirming the rollback.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1. Resolve Project and Env
-		projectName := rollbackProject
-		envName := rollbackEnv
-
-		// Prompt for Project if missing
-		if projectName == "" {
-			form := huh.NewForm(
-				huh.NewGroup(
-					huh.NewInput().
-						Title("Project Name").
-						Value(&projectName).
-						Validate(func(str string) error {
-							if str == "" {
-								return fmt.Errorf("project name is required")
-							}
-							return nil
-						}),
-				),
-			)
-			if err := form.Run(); err != nil {
-				return Error("cancelled", nil)
-			}
-		}
-
-		// Prompt for Env if missing
-		if envName == "" {
-			form := huh.NewForm(
-				huh.NewGroup(
-					huh.NewInput().
-						Title("Environment Name").
-						Value(&envName).
-						Validate(func(str string) error {
-							if str == "" {
-								return fmt.Errorf("environment name is required")
-							}
-							return nil
-						}),
-				),
-			)
-			if err := form.Run(); err != nil {
-				return Error("cancelled", nil)
-			}
+		projectName, envName, err := resolveTarget(rollbackProject, "", rollbackEnv)
+		if err != nil {
+			return Error("failed to resolve project", err)
 		}
 
 		// 2. Fetch all versions
@@ -104,12 +66,10 @@ You will see a diff of the changes before confirming the rollback.`,
 			targetVersion = &v32
 		} else {
 			// Interactive selection
+			changes := versionChanges(versions)
 			options := make([]huh.Option[int], 0, len(versions))
 			for i, v := range versions {
-				label := fmt.Sprintf("v%d", v.Version)
-				if i == 0 {
-					label += " (Current)"
-				}
+				label := versionLabel(v, changes[v.Version], i == 0)
 				// Don't allowing rolling back to the current version?
 				// Actually, it might be useful if the current version is somehow messed up in a way that is not purely data but metadata?
 				// But generally rollback implies going back.
@@ -185,7 +145,7 @@ func init() {
 	rootCmd.AddCommand(rollbackCmd)
 
 	rollbackCmd.Flags().StringVarP(&rollbackProject, "project", "p", "", "Project name")
-	rollbackCmd.Flags().StringVarP(&rollbackEnv, "env", "e", "", "Environment name")
+	rollbackCmd.Flags().StringVarP(&rollbackEnv, "env", "e", "", "Environment name (default: from .envcrypt.yaml or dev)")
 	rollbackCmd.Flags().IntVarP(&rollbackVer, "version", "v", 0, "Version to rollback to")
 	rollbackCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show actual secret values in diff output")
 }
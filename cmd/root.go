@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/envcrypts/envcrypt-cli/internal/app"
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var Version = "dev"
@@ -33,13 +39,102 @@ var rootCmd = &cobra.Command{
 
 var Application *app.App
 
-func Execute(a *app.App) {
-	Application = a
+var (
+	contextName  string
+	keystoreName string
+)
+
+func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError makes Execute exit with a specific status instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// setupApplication selects the context and builds the App for it. It runs
+// after flag parsing so --context can pick the server and identity.
+func setupApplication(cmd *cobra.Command, args []string) error {
+	if contextName != "" {
+		if err := config.SetContextOverride(contextName); err != nil {
+			return Error("invalid context", err)
+		}
+	} else if envContext := os.Getenv("ENVCRYPT_CONTEXT"); envContext != "" {
+		if err := config.SetContextOverride(envContext); err != nil {
+			return Error("invalid ENVCRYPT_CONTEXT", err)
+		}
+	}
+
+	if keystoreName != "" {
+		viper.Set("keystore", keystoreName)
+	}
+	cryptoutils.PassphrasePrompt = promptKeystorePassphrase
+
+	Application = app.NewApp(config.BaseURL())
+	Application.Warn = func(msg string) {
+		// stderr, so warnings never end up in piped output
+		fmt.Fprintf(os.Stderr, "%s %s\n", iconWarn, msg)
+	}
+	return nil
+}
+
+// promptKeystorePassphrase reads the file keystore passphrase from the
+// terminal. Prompts go to stderr so piped output such as pull --stdout stays
+// clean.
+func promptKeystorePassphrase(create bool) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("the keystore is locked; set ENVCRYPT_KEYSTORE_PASSPHRASE")
+	}
+
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(passphrase), err
+	}
+
+	if !create {
+		return read("Keystore passphrase: ")
+	}
+
+	fmt.Fprintln(os.Stderr, "No OS keyring available; creating an encrypted keystore file.")
+	passphrase, err := read("New keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := read("Repeat keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
 func init() {
+	rootCmd.PersistentPreRunE = setupApplication
+
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Context to use for this command (see 'envcrypt context list')")
+	rootCmd.PersistentFlags().StringVar(&keystoreName, "keystore", "", "Where to keep private keys: auto, keyring or file (default: auto)")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	runProject string
+	runEnvName string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [project] -- <command> [args...]",
+	Short: "Run a command with decrypted environment variables",
+	Long: `Decrypt a project environment and run a command with the variables
+injected into its environment. Nothing is written to disk.
+
+The command's exit code is passed through, and signals received by envcrypt
+are forwarded to the command.
+
+Example:
+  envcrypt run my-app --env prod -- ./server --port 8080`,
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dash := cmd.ArgsLenAtDash()
+		if dash < 0 {
+			return Error("no command given", errors.New("usage: envcrypt run [project] -- <command> [args...]"))
+		}
+		if dash > 1 {
+			return Error("too many arguments before --", nil)
+		}
+
+		command := args[dash:]
+		if len(command) == 0 {
+			return Error("no command given", nil)
+		}
+
+		projectName, envName, err := resolveTarget(runProject, firstArg(args[:dash]), runEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		envMap, err := Application.PullEnv(cmd.Context(), projectName, envName)
+		if err != nil {
+			return Error("failed to pull environment variables", err)
+		}
+
+		code, err := runWithEnv(command, envMap)
+		if err != nil {
+			return Error("failed to run command", err)
+		}
+
+		if code != 0 {
+			// Pass the command's status through without a message of our own.
+			cmd.SilenceErrors = true
+			return &exitError{code: code}
+		}
+		return nil
+	},
+}
+
+// runWithEnv starts the command with envMap layered over the current
+// environment, forwards signals until it exits and returns its exit code.
+func runWithEnv(command []string, envMap map[string]string) (int, error) {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return 0, err
+	}
+
+	child := exec.Command(path, command[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = mergeEnv(os.Environ(), envMap)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+
+	if err := child.Start(); err != nil {
+		return 0, err
+	}
+
+	go func() {
+		for sig := range sigs {
+			_ = child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, err
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
+
+// mergeEnv overrides entries of base with the values in envMap.
+func mergeEnv(base []string, envMap map[string]string) []string {
+	merged := make([]string, 0, len(base)+len(envMap))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := envMap[key]; ok {
+			continue
+		}
+		merged = append(merged, kv)
+	}
+
+	for k, v := range envMap {
+		merged = append(merged, k+"="+v)
+	}
+
+	return merged
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVar(&runProject, "project", "", "Project name")
+	runCmd.Flags().StringVar(&runEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	setProject string
+	setEnvName string
+	setMessage string
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var setCmd = &cobra.Command{
+	Use:   "set KEY=VALUE [KEY=VALUE...]",
+	Short: "Set one or more environment variables",
+	Long: `Set one or more variables on top of the latest version of an environment
+and upload the result as a new version.
+
+Example:
+  envcrypt set --project my-app --env prod API_URL=https://api.example.com`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName, envName, err := resolveTarget(setProject, "", setEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		values := make(map[string]string, len(args))
+		for _, arg := range args {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return Error(fmt.Sprintf("invalid assignment %q", arg), fmt.Errorf("expected KEY=VALUE"))
+			}
+			if !envKeyPattern.MatchString(key) {
+				return Error(fmt.Sprintf("invalid key %q", key), nil)
+			}
+			values[key] = value
+		}
+
+		if err := Application.SetEnvKeys(cmd.Context(), projectName, envName, values, setMessage); err != nil {
+			return Error("failed to set environment variables", err)
+		}
+
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		Success(fmt.Sprintf("Set %s on %s/%s", strings.Join(keys, ", "), projectName, envName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setCmd)
+
+	setCmd.Flags().StringVar(&setProject, "project", "", "Project name")
+	setCmd.Flags().StringVar(&setEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	setCmd.Flags().StringVarP(&setMessage, "message", "m", "", "Describe the change")
+}
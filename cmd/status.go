@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/envcrypts/envcrypt-cli/internal/formats"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusProject     string
+	statusEnvName     string
+	statusEnvFile     string
+	statusShowSecrets bool
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [project]",
+	Short: "Compare the local .env file against the remote environment",
+	Long: `Show which keys were added, removed or modified in the local .env file
+compared to the latest remote version, and whether the file is behind or ahead
+of the remote.
+
+Exits with status 0 when the file matches the remote, 1 when it differs and
+2 when the comparison failed, so it can be used in scripts and git hooks.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inSync, err := runStatus(cmd, args)
+		if err != nil {
+			return &exitError{code: 2, err: err}
+		}
+		if !inSync {
+			// Differing is a result, not an error; exit 1 without a message.
+			cmd.SilenceErrors = true
+			return &exitError{code: 1}
+		}
+		return nil
+	},
+}
+
+// runStatus prints how the local file compares to the remote and reports
+// whether they match.
+func runStatus(cmd *cobra.Command, args []string) (bool, error) {
+	projectName, envName, err := resolveTarget(statusProject, firstArg(args), statusEnvName)
+	if err != nil {
+		return false, Error("failed to resolve project", err)
+	}
+
+	envPath, err := resolveEnvFile(statusEnvFile)
+	if err != nil {
+		return false, Error("failed to load env file", err)
+	}
+
+	fileData, err := os.ReadFile(envPath)
+	if err != nil {
+		return false, Error("failed to read env file", mapEnvReadError(envPath, err))
+	}
+
+	format := formats.DetectFormat(envPath)
+	localMap, err := formats.Decode(format, fileData)
+	if err != nil {
+		return false, Error(fmt.Sprintf("failed to parse %s as %s", envPath, format), err)
+	}
+
+	head, err := Application.PullEnvVersion(cmd.Context(), projectName, envName, nil)
+	if err != nil {
+		return false, Error("failed to pull environment variables", err)
+	}
+
+	state, err := config.LoadEnvState(projectName, envName)
+	if err != nil {
+		return false, Error("failed to read local state", err)
+	}
+	if state != nil && state.BaseVersion == 0 {
+		// Only the remote head has been recorded, not a local file.
+		state = nil
+	}
+
+	Info(fmt.Sprintf("Project: %s/%s", projectName, envName))
+	if state != nil {
+		Info(fmt.Sprintf("Local:  %s (based on v%d)", envPath, state.BaseVersion))
+	} else {
+		Info(fmt.Sprintf("Local:  %s (base version unknown)", envPath))
+	}
+	Info(fmt.Sprintf("Remote: v%d", head.Version))
+	Spacer()
+
+	diff := cryptoutils.DiffEnvVersions(head.Env, localMap)
+	inSync := len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Modified) == 0
+
+	behind := state != nil && state.BaseVersion < head.Version
+	ahead := !inSync
+	if state != nil && behind && !inSync {
+		// Only count the file as ahead if it changed since its base.
+		base, err := Application.PullEnvVersion(cmd.Context(), projectName, envName, &state.BaseVersion)
+		if err == nil {
+			local := cryptoutils.DiffEnvVersions(base.Env, localMap)
+			ahead = len(local.Added) > 0 || len(local.Removed) > 0 || len(local.Modified) > 0
+		}
+	}
+
+	switch {
+	case inSync:
+		Success(fmt.Sprintf("Up to date with v%d", head.Version))
+	case state == nil:
+		Warn(fmt.Sprintf("The file differs from v%d", head.Version))
+	case behind && ahead:
+		Warn(fmt.Sprintf("Diverged: the remote moved %d version(s) past v%d and the file has local changes", head.Version-state.BaseVersion, state.BaseVersion))
+	case behind:
+		Warn(fmt.Sprintf("Behind: the remote moved %d version(s) past v%d; run pull to update", head.Version-state.BaseVersion, state.BaseVersion))
+	default:
+		Warn("Ahead: the file has changes that have not been pushed")
+	}
+
+	if inSync {
+		return true, nil
+	}
+
+	Spacer()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Changes (v%d → %s)", head.Version, envPath)))
+	renderDiff(diff, head.Env, localMap, statusShowSecrets)
+
+	return false, nil
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVar(&statusProject, "project", "", "Project name")
+	statusCmd.Flags().StringVar(&statusEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	statusCmd.Flags().StringVarP(&statusEnvFile, "env-file", "e", "", "Path to .env file (default: ./.env)")
+	statusCmd.Flags().BoolVar(&statusShowSecrets, "show-secrets", false, "Show actual secret values in diff output")
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/envcrypts/envcrypt-cli/internal/app"
 	"github.com/envcrypts/envcrypt-cli/internal/config"
 	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
 )
@@ -75,6 +76,17 @@ func ConfirmDangerousAction(prompt, expected string) bool {
 	return true
 }
 
+func Confirm(prompt string) bool {
+	Warn(prompt)
+	fmt.Printf("%s [y/N]: ", mutedStyle.Render("?"))
+
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.ToLower(strings.TrimSpace(input))
+
+	return input == "y" || input == "yes"
+}
+
 func ConfirmOverwrite(path string) bool {
 	Warn(fmt.Sprintf("Overwrite %q?", path))
 	fmt.Printf("%s [y/N]: ", mutedStyle.Render("?"))
@@ -200,6 +212,138 @@ func renderDiff(diff cryptoutils.DiffingResult, oldMap, newMap map[string]string
 	}
 }
 
+// renderEnvComparison lists the keys present in only one of two
+// environments. When compareValues is set, keys present in both whose values
+// differ are listed too, with values shown through fingerprint if it is not
+// nil and in plain text otherwise.
+func renderEnvComparison(leftName, rightName string, left, right map[string]string, compareValues bool, fingerprint func(string) string) {
+	diff := cryptoutils.DiffEnvVersions(left, right)
+
+	onlyLeft := diff.Removed
+	onlyRight := diff.Added
+
+	if len(onlyLeft) == 0 && len(onlyRight) == 0 && (!compareValues || len(diff.Modified) == 0) {
+		if compareValues {
+			fmt.Println(mutedStyle.Render("No differences."))
+		} else {
+			fmt.Println(mutedStyle.Render("Both environments define the same keys."))
+		}
+		return
+	}
+
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	modifiedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+
+	if len(onlyLeft) > 0 {
+		fmt.Println(headerStyle.Render(fmt.Sprintf("Only in %s (%d)", leftName, len(onlyLeft))))
+		for _, key := range onlyLeft {
+			fmt.Println(removedStyle.Render("- " + key))
+		}
+		Spacer()
+	}
+
+	if len(onlyRight) > 0 {
+		fmt.Println(headerStyle.Render(fmt.Sprintf("Only in %s (%d)", rightName, len(onlyRight))))
+		for _, key := range onlyRight {
+			fmt.Println(addedStyle.Render("+ " + key))
+		}
+		Spacer()
+	}
+
+	if !compareValues {
+		fmt.Println(mutedStyle.Render("Values were not compared. Use --fingerprints or --show-secrets to compare them."))
+		return
+	}
+
+	if len(diff.Modified) > 0 {
+		show := func(val string) string {
+			if fingerprint != nil {
+				return fingerprint(val)
+			}
+			return val
+		}
+
+		fmt.Println(headerStyle.Render(fmt.Sprintf("Different values (%d)", len(diff.Modified))))
+		for _, key := range diff.Modified {
+			fmt.Println(modifiedStyle.Render(fmt.Sprintf("~ %s: %s → %s", key, show(left[key]), show(right[key]))))
+		}
+	}
+}
+
+func renderPushConflict(conflict *app.PushConflictError) {
+	base := "unknown base"
+	if conflict.BaseVersion != 0 {
+		base = fmt.Sprintf("v%d", conflict.BaseVersion)
+	}
+
+	Spacer()
+	Warn(fmt.Sprintf("Remote head is v%d, but your changes are based on %s", conflict.HeadVersion, base))
+
+	Spacer()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Your changes (%s → local)", base)))
+	renderDiff(cryptoutils.DiffEnvVersions(conflict.Base, conflict.Local), conflict.Base, conflict.Local, false)
+
+	Spacer()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("Their changes (%s → v%d)", base, conflict.HeadVersion)))
+	renderDiff(cryptoutils.DiffEnvVersions(conflict.Base, conflict.Head), conflict.Base, conflict.Head, false)
+
+	Spacer()
+	fmt.Println(headerStyle.Render("Conflicting keys"))
+	for _, key := range conflict.Conflicts {
+		fmt.Printf("  %s\n", errorStyle.Render(key))
+	}
+	Spacer()
+}
+
+// versionChanges diffs every version against the version before it.
+func versionChanges(versions []app.DecryptedEnvVersion) map[int32]cryptoutils.DiffingResult {
+	sorted := make([]app.DecryptedEnvVersion, len(versions))
+	copy(sorted, versions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	changes := make(map[int32]cryptoutils.DiffingResult, len(sorted))
+	prev := map[string]string{}
+	for _, v := range sorted {
+		changes[v.Version] = cryptoutils.DiffEnvVersions(prev, v.Env)
+		prev = v.Env
+	}
+	return changes
+}
+
+func formatChangeCounts(diff cryptoutils.DiffingResult) string {
+	return fmt.Sprintf("+%d -%d ~%d", len(diff.Added), len(diff.Removed), len(diff.Modified))
+}
+
+func formatVersionTime(v app.DecryptedEnvVersion) string {
+	if v.Metadata.CreatedAt.IsZero() {
+		return ""
+	}
+	return v.Metadata.CreatedAt.Local().Format("2006-01-02 15:04")
+}
+
+// versionLabel is a one-line description of a version for selectors.
+func versionLabel(v app.DecryptedEnvVersion, diff cryptoutils.DiffingResult, current bool) string {
+	label := fmt.Sprintf("v%d", v.Version)
+	if current {
+		label += " (Current)"
+	}
+
+	parts := []string{label}
+	for _, p := range []string{v.Metadata.Type, v.Metadata.Author, formatVersionTime(v), formatChangeCounts(diff)} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if v.Metadata.Message != "" {
+		parts = append(parts, truncate(v.Metadata.Message, 40))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
 func PrintServiceRoles(roles []config.ServiceRole) {
 	if len(roles) == 0 {
 		fmt.Println(mutedStyle.Render("No service roles found."))
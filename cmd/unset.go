@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	unsetProject string
+	unsetEnvName string
+	unsetMessage string
+)
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset KEY [KEY...]",
+	Short: "Remove one or more environment variables",
+	Long: `Remove one or more variables from the latest version of an environment
+and upload the result as a new version.`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName, envName, err := resolveTarget(unsetProject, "", unsetEnvName)
+		if err != nil {
+			return Error("failed to resolve project", err)
+		}
+
+		keys := make([]string, 0, len(args))
+		for _, k := range args {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+
+		missing, err := Application.UnsetEnvKeys(cmd.Context(), projectName, envName, keys, unsetMessage)
+		if err != nil {
+			return Error("failed to unset environment variables", err)
+		}
+
+		for _, k := range missing {
+			Warn(fmt.Sprintf("%s is not set, skipping", k))
+		}
+
+		removed := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if !slices.Contains(missing, k) {
+				removed = append(removed, k)
+			}
+		}
+
+		Success(fmt.Sprintf("Removed %s from %s/%s", strings.Join(removed, ", "), projectName, envName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unsetCmd)
+
+	unsetCmd.Flags().StringVar(&unsetProject, "project", "", "Project name")
+	unsetCmd.Flags().StringVar(&unsetEnvName, "env", "", "Environment name (default: from .envcrypt.yaml or dev)")
+	unsetCmd.Flags().StringVarP(&unsetMessage, "message", "m", "", "Describe the change")
+}
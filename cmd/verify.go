@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <email>",
+	Short: "Verify a member's public key",
+	Long: `Show the safety number you share with another user.
+
+Keys are trusted on first use and pinned locally; adding a member or reading
+their versions fails if the server later hands out a different key, or a
+signing key the member published after the pin. Compare
+the safety number with the other user in person or over a channel you trust.
+When they match, confirm to mark the key as verified, or to accept a key that
+changed because the user registered again.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		email := args[0]
+
+		v, err := Application.VerifyUser(cmd.Context(), email)
+		if err != nil {
+			return Error("failed to verify key", err)
+		}
+
+		Info("User:        " + v.Email)
+		Info("Fingerprint: " + v.Fingerprint)
+		Spacer()
+		fmt.Println("Safety number:")
+		groups := strings.Fields(v.SafetyNumber)
+		for i := 0; i < len(groups); i += 4 {
+			fmt.Println("  " + strings.Join(groups[i:min(i+4, len(groups))], " "))
+		}
+		Spacer()
+
+		switch {
+		case v.Changed:
+			Warn(fmt.Sprintf("The key of %s changed since it was pinned on %s", email, v.Pinned.FirstSeen.Local().Format("2006-01-02")))
+		case v.NewSigningKey:
+			Warn(fmt.Sprintf("%s published a signing key since their key was pinned; it is only trusted once verified", email))
+		case v.Pinned != nil && v.Pinned.Verified:
+			Success("This key was verified before")
+			return nil
+		}
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			Info("Run this command in a terminal to mark the key as verified")
+			return nil
+		}
+
+		if !Confirm(fmt.Sprintf("Does %s see the same safety number?", email)) {
+			if v.Changed {
+				return Error("key not accepted", fmt.Errorf("the key pinned before is kept for %s", email))
+			}
+			fmt.Println(mutedStyle.Render("Not verified."))
+			return nil
+		}
+
+		if err := Application.TrustKey(v); err != nil {
+			return Error("failed to save key", err)
+		}
+		Success("Marked the key of " + email + " as verified")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -9,13 +13,13 @@ import (
 var whoamiCmd = &cobra.Command{
 	Use:          "whoami",
 	Short:        "Show the current authenticated user",
-	Long:         "Display the identity currently logged into EnvCrypt.",
+	Long:         "Display the identity currently logged into EnvCrypt and the fingerprint of its key.",
 	Args:         cobra.NoArgs,
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		email := viper.GetString("user.email")
-		userID := viper.GetString("user.id")
+		email := viper.GetString(config.ContextKey("user.email"))
+		userID := viper.GetString(config.ContextKey("user.id"))
 
 		if email == "" {
 			return Error(
@@ -30,6 +34,21 @@ var whoamiCmd = &cobra.Command{
 			Info("User ID: " + userID)
 		}
 
+		keys, err := Application.LocalKeys()
+		if err != nil {
+			Warn(fmt.Sprintf("Could not read your key: %v", err))
+			return nil
+		}
+		Info("Key fingerprint: " + cryptoutils.Fingerprint(keys.PublicKey))
+
+		matches, err := Application.PublishedKeyMatches(cmd.Context(), keys)
+		switch {
+		case err != nil:
+			Warn(fmt.Sprintf("Could not compare with the key published by the server: %v", err))
+		case !matches:
+			Warn("Your keystore holds a different key than the server publishes for this account; run 'envcrypt login' again")
+		}
+
 		return nil
 	},
 }
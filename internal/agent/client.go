@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+)
+
+// Client talks to a running agent.
+type Client struct {
+	path string
+}
+
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// RemoteError is an error reported by the agent, as opposed to a failure to
+// reach it.
+type RemoteError struct {
+	Msg string
+}
+
+func (e *RemoteError) Error() string {
+	return "agent: " + e.Msg
+}
+
+// Unwrap returns the PMK for a key wrapped to the account, or ErrNoKey when
+// the agent does not hold the account's private key.
+func (c *Client) Unwrap(service, account string, wrapped *cryptoutils.WrappedKey) ([]byte, error) {
+	resp, err := c.call(request{
+		Op:                 opUnwrap,
+		Service:            service,
+		Account:            account,
+		WrappedPMK:         wrapped.WrappedPMK,
+		WrapNonce:          wrapped.WrapNonce,
+		EphemeralPublicKey: wrapped.WrapEphemeralPub,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.PMK, nil
+}
+
+// Sign signs msg with the account's signing key, or returns ErrNoKey when
+// the agent does not hold the account's private key.
+func (c *Client) Sign(service, account string, msg []byte) ([]byte, error) {
+	resp, err := c.call(request{
+		Op:      opSign,
+		Service: service,
+		Account: account,
+		Message: msg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+// PublicKeys returns the public and signing keys of the account, or ErrNoKey
+// when the agent does not hold the account's private key.
+func (c *Client) PublicKeys(service, account string) ([]byte, []byte, error) {
+	resp, err := c.call(request{
+		Op:      opPublic,
+		Service: service,
+		Account: account,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.PublicKey, resp.SigningPublicKey, nil
+}
+
+// AddKey hands the agent a private key, which it keeps for its TTL.
+func (c *Client) AddKey(service, account string, privateKey []byte) error {
+	_, err := c.call(request{
+		Op:         opAddKey,
+		Service:    service,
+		Account:    account,
+		PrivateKey: privateKey,
+	})
+	return err
+}
+
+// Lock makes the agent forget every cached key.
+func (c *Client) Lock() error {
+	_, err := c.call(request{Op: opLock})
+	return err
+}
+
+func (c *Client) call(req request) (*response, error) {
+	conn, err := net.DialTimeout("unix", c.path, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	switch resp.Error {
+	case "":
+		return &resp, nil
+	case errNoKeyCode:
+		return nil, ErrNoKey
+	default:
+		return nil, &RemoteError{Msg: resp.Error}
+	}
+}
+
+// IsUnavailable reports whether err means the agent could not be reached,
+// in which case callers fall back to the local keystore.
+func IsUnavailable(err error) bool {
+	var remote *RemoteError
+	return err != nil && !errors.Is(err, ErrNoKey) && !errors.As(err, &remote)
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+)
+
+// SocketEnv names the environment variable pointing clients at the agent.
+const SocketEnv = "ENVCRYPT_AGENT_SOCK"
+
+const (
+	opUnwrap = "unwrap"
+	opAddKey = "add_key"
+	opLock   = "lock"
+	opSign   = "sign"
+	opPublic = "public_keys"
+)
+
+// ErrNoKey is returned by Unwrap when the agent does not hold the private
+// key of the account; the caller should add it with AddKey.
+var ErrNoKey = errors.New("agent has no key for this account")
+
+const errNoKeyCode = "no_key"
+
+// request is sent as a single JSON document per connection.
+type request struct {
+	Op      string `json:"op"`
+	Service string `json:"service,omitempty"`
+	Account string `json:"account,omitempty"`
+
+	PrivateKey []byte `json:"private_key,omitempty"`
+
+	WrappedPMK         []byte `json:"wrapped_pmk,omitempty"`
+	WrapNonce          []byte `json:"wrap_nonce,omitempty"`
+	EphemeralPublicKey []byte `json:"ephemeral_public_key,omitempty"`
+
+	Message []byte `json:"message,omitempty"`
+}
+
+type response struct {
+	Error     string `json:"error,omitempty"`
+	PMK       []byte `json:"pmk,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+
+	PublicKey        []byte `json:"public_key,omitempty"`
+	SigningPublicKey []byte `json:"signing_public_key,omitempty"`
+}
+
+// DefaultSocketPath returns $XDG_RUNTIME_DIR/envcrypt/agent.sock, or a
+// socket in the config directory when there is no runtime directory.
+func DefaultSocketPath() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "envcrypt", "agent.sock"), nil
+	}
+
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "agent.sock"), nil
+}
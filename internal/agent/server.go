@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+)
+
+type cachedSecret struct {
+	value   []byte
+	expires time.Time
+}
+
+// Server keeps private keys and unwrapped project master keys in memory for
+// ttl after they were added, and unwraps PMKs for clients on the socket.
+type Server struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	keys map[string]cachedSecret // service/account -> private key
+	pmks map[string]cachedSecret // hash of the wrapped key -> PMK
+}
+
+func NewServer(ttl time.Duration) *Server {
+	return &Server{
+		ttl:  ttl,
+		keys: map[string]cachedSecret{},
+		pmks: map[string]cachedSecret{},
+	}
+}
+
+// Listen creates the Unix socket at path, readable only by the current user.
+// A stale socket left by a crashed agent is replaced.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, errors.New("an agent is already listening on " + path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// Serve handles connections until the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	go s.expireLoop()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Lock forgets every cached secret.
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range s.keys {
+		zero(v.value)
+		delete(s.keys, k)
+	}
+	for k, v := range s.pmks {
+		zero(v.value)
+		delete(s.pmks, k)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	var resp response
+	switch req.Op {
+	case opUnwrap:
+		pmk, err := s.unwrap(req)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.PMK = pmk
+	case opSign:
+		signature, err := s.sign(req)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Signature = signature
+	case opPublic:
+		publicKey, signingKey, err := s.publicKeys(req)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.PublicKey, resp.SigningPublicKey = publicKey, signingKey
+	case opAddKey:
+		s.addKey(req)
+	case opLock:
+		s.Lock()
+	default:
+		resp.Error = "unknown operation " + req.Op
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+func (s *Server) addKey(req request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[req.Service+"/"+req.Account] = cachedSecret{
+		value:   append([]byte(nil), req.PrivateKey...),
+		expires: time.Now().Add(s.ttl),
+	}
+}
+
+func (s *Server) unwrap(req request) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The wrapped blob is unique per member and key, so a rotated key is
+	// never served from the cache.
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(req.Service), []byte(req.Account), req.WrappedPMK, req.WrapNonce, req.EphemeralPublicKey} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	cacheKey := hex.EncodeToString(h.Sum(nil))
+
+	now := time.Now()
+	if pmk, ok := s.pmks[cacheKey]; ok && now.Before(pmk.expires) {
+		return append([]byte(nil), pmk.value...), nil
+	}
+
+	key, ok := s.keys[req.Service+"/"+req.Account]
+	if !ok || !now.Before(key.expires) {
+		return nil, errors.New(errNoKeyCode)
+	}
+
+	pmk, err := cryptoutils.UnwrapPMK(&cryptoutils.WrappedKey{
+		WrappedPMK:       req.WrappedPMK,
+		WrapNonce:        req.WrapNonce,
+		WrapEphemeralPub: req.EphemeralPublicKey,
+	}, key.value)
+	if err != nil {
+		return nil, errors.New("could not unwrap project key")
+	}
+
+	s.pmks[cacheKey] = cachedSecret{value: pmk, expires: key.expires}
+	return append([]byte(nil), pmk...), nil
+}
+
+// sign signs a message with the signing key derived from the account's
+// private key.
+func (s *Server) sign(req request) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[req.Service+"/"+req.Account]
+	if !ok || !time.Now().Before(key.expires) {
+		return nil, errors.New(errNoKeyCode)
+	}
+
+	return cryptoutils.SignMessage(key.value, req.Message)
+}
+
+// publicKeys returns the public and signing keys of the account's private
+// key.
+func (s *Server) publicKeys(req request) ([]byte, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[req.Service+"/"+req.Account]
+	if !ok || !time.Now().Before(key.expires) {
+		return nil, nil, errors.New(errNoKeyCode)
+	}
+
+	publicKey, err := cryptoutils.PublicKeyFromPrivate(key.value)
+	if err != nil {
+		return nil, nil, err
+	}
+	signingKey, err := cryptoutils.SigningPublicKey(key.value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publicKey, signingKey, nil
+}
+
+func (s *Server) expireLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.mu.Lock()
+		for k, v := range s.keys {
+			if !now.Before(v.expires) {
+				zero(v.value)
+				delete(s.keys, k)
+			}
+		}
+		for k, v := range s.pmks {
+			if !now.Before(v.expires) {
+				zero(v.value)
+				delete(s.pmks, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
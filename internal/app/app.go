@@ -2,17 +2,58 @@ package app
 
 import (
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/envcrypts/envcrypt-cli/internal/agent"
 	"github.com/envcrypts/envcrypt-cli/internal/client"
 )
 
+// requestTimeout bounds every request to the server, so a hung connection
+// cannot stall a command forever.
+const requestTimeout = time.Minute
+
 type App struct {
 	HttpClient *client.Client
+
+	// Agent caches unwrapped project keys when ENVCRYPT_AGENT_SOCK is set.
+	Agent *agent.Client
+
+	// Warn reports problems that do not stop a command, such as unsigned
+	// versions. It may be nil.
+	Warn func(msg string)
+
+	signingKeys map[string][]byte
+	warned      map[string]bool
+}
+
+func (app *App) warn(msg string) {
+	if app.Warn != nil {
+		app.Warn(msg)
+	}
+}
+
+// warnOnce reports msg only the first time key is seen, so listing many
+// versions does not repeat the same warning.
+func (app *App) warnOnce(key, msg string) {
+	if app.warned[key] {
+		return
+	}
+	app.warned[key] = true
+	app.warn(msg)
 }
 
 func NewApp(baseUrl string) *App {
-	httpClient := client.NewClient(baseUrl, &http.Client{})
-	return &App{
-		HttpClient: httpClient,
+	httpClient := client.NewClient(baseUrl, &http.Client{Timeout: requestTimeout})
+	app := &App{
+		HttpClient:  httpClient,
+		signingKeys: map[string][]byte{},
+		warned:      map[string]bool{},
 	}
+
+	if sock := os.Getenv(agent.SocketEnv); sock != "" {
+		app.Agent = agent.NewClient(sock)
+	}
+
+	return app
 }
@@ -1,8 +1,11 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdh"
 	"errors"
+	"fmt"
 
 	"github.com/envcrypts/envcrypt-cli/internal/config"
 	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
@@ -10,7 +13,16 @@ import (
 	"github.com/spf13/viper"
 )
 
-func (app *App) Login(ctx context.Context, email, password string) error {
+type LoginResult struct {
+	// KeyUpgraded is set when the private key was re-encrypted with the
+	// current Argon2id defaults and encryption format because it was stored
+	// with weaker parameters or an older format.
+	KeyUpgraded bool
+	// UpgradeErr is set when that upgrade failed; the login itself succeeded.
+	UpgradeErr error
+}
+
+func (app *App) Login(ctx context.Context, email, password string) (*LoginResult, error) {
 
 	requestBody := config.LoginRequestBody{
 		Email:    email,
@@ -21,36 +33,109 @@ func (app *App) Login(ctx context.Context, email, password string) error {
 
 	err := app.HttpClient.Do(ctx, "POST", "/users/login", requestBody, &responseBody, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	encryptedKey := &config.EncryptedPrivateKey{
 		EncryptedUserPrivateKey: responseBody.User.EncryptedUserPrivateKey,
 		PrivateKeySalt:          responseBody.User.PrivateKeySalt,
 		PrivateKeyNonce:         responseBody.User.PrivateKeyNonce,
+		ArgonParams:             responseBody.User.ArgonParams,
 	}
 
-	decryptedPrivateKey, err := cryptoutils.DecryptPrivateKey(encryptedKey, password, &config.DefaultArgon2Params)
+	argonParams := responseBody.User.ArgonParams.ParamsOrDefault()
+	if err := argonParams.Validate(); err != nil {
+		return nil, err
+	}
+	decryptedPrivateKey, err := cryptoutils.DecryptPrivateKey(encryptedKey, password, &argonParams)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = cryptoutils.SavePrivateKey(email, decryptedPrivateKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = cryptoutils.SaveUserEmail(email)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = cryptoutils.SaveUserId(responseBody.User.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.HttpClient.SetSession(responseBody.Session.Session())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pinOwnKey(email, decryptedPrivateKey); err != nil {
+		return nil, err
+	}
+	if err := config.SaveAccountKey(email, *encryptedKey); err != nil {
+		return nil, err
+	}
+
+	if len(responseBody.User.SigningPublicKey) == 0 {
+		if err := app.publishSigningKey(ctx, responseBody.User.Id, decryptedPrivateKey); err != nil {
+			app.warn(fmt.Sprintf("Could not publish your signing key: %v", err))
+		}
+	}
+
+	result := &LoginResult{}
+	legacyKey := !cryptoutils.IsEnvelope(responseBody.User.EncryptedUserPrivateKey)
+	if legacyKey || argonParams.WeakerThan(config.DefaultArgon2Params) {
+		result.UpgradeErr = app.upgradePrivateKey(ctx, email, responseBody.User.Id, decryptedPrivateKey, password)
+		result.KeyUpgraded = result.UpgradeErr == nil
+	}
+
+	return result, nil
+}
+
+// publishSigningKey uploads the signing key derived from the private key,
+// for accounts created before versions were signed.
+func (app *App) publishSigningKey(ctx context.Context, userId uuid.UUID, privateKey []byte) error {
+	signingKey, err := cryptoutils.SigningPublicKey(privateKey)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	requestBody := config.SigningKeyRequestBody{
+		UserID:           userId,
+		SigningPublicKey: signingKey,
+	}
+	var responseBody config.SigningKeyResponseBody
+	return app.HttpClient.Do(ctx, "POST", "/users/signing-key", requestBody, &responseBody, true)
+}
+
+// upgradePrivateKey re-encrypts the private key with the current Argon2id
+// defaults and uploads it.
+func (app *App) upgradePrivateKey(ctx context.Context, email string, userId uuid.UUID, privateKeyBytes []byte, password string) error {
+	privateKey, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
+	if err != nil {
+		return err
+	}
+
+	encryptedKey, err := cryptoutils.EncryptPrivateKey(privateKey, password, &config.DefaultArgon2Params)
+	if err != nil {
+		return err
+	}
+
+	requestBody := config.UpdatePrivateKeyRequestBody{
+		UserID:                  userId,
+		EncryptedUserPrivateKey: encryptedKey.EncryptedUserPrivateKey,
+		PrivateKeySalt:          encryptedKey.PrivateKeySalt,
+		PrivateKeyNonce:         encryptedKey.PrivateKeyNonce,
+		ArgonParams:             encryptedKey.ArgonParams,
+	}
+	var responseBody config.UpdatePrivateKeyResponseBody
+	if err := app.HttpClient.Do(ctx, "POST", "/users/keys/update", requestBody, &responseBody, true); err != nil {
+		return err
+	}
+	return config.SaveAccountKey(email, *encryptedKey)
 }
 
 func (app *App) Register(ctx context.Context, email, password string) error {
@@ -60,6 +145,11 @@ func (app *App) Register(ctx context.Context, email, password string) error {
 		return err
 	}
 
+	signingKey, err := cryptoutils.SigningPublicKey(keypair.PrivateKey)
+	if err != nil {
+		return err
+	}
+
 	requestBody := config.CreateRequestBody{
 		Email:                   email,
 		Password:                password,
@@ -67,6 +157,8 @@ func (app *App) Register(ctx context.Context, email, password string) error {
 		EncryptedUserPrivateKey: keypair.EncKey.EncryptedUserPrivateKey,
 		PrivateKeySalt:          keypair.EncKey.PrivateKeySalt,
 		PrivateKeyNonce:         keypair.EncKey.PrivateKeyNonce,
+		ArgonParams:             keypair.EncKey.ArgonParams,
+		SigningPublicKey:        signingKey,
 	}
 	var responseBody config.CreateResponseBody
 
@@ -90,13 +182,97 @@ func (app *App) Register(ctx context.Context, email, password string) error {
 		return err
 	}
 
-	return nil
+	err = app.HttpClient.SetSession(responseBody.Session.Session())
+	if err != nil {
+		return err
+	}
+
+	if err := config.SaveAccountKey(email, keypair.EncKey); err != nil {
+		return err
+	}
+	return pinOwnKey(email, keypair.PrivateKey)
+}
+
+// ChangePassword re-encrypts the private key under newPassword with a fresh
+// salt. The keypair itself is unchanged, so project key wrappings stay valid.
+func (app *App) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
+	email, userId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
+	uid, err := uuid.Parse(userId)
+	if err != nil || email == "" {
+		return errors.New("user not authenticated")
+	}
+
+	_, privateKey, err := decryptAccountKey(email, oldPassword)
+	if err != nil {
+		return err
+	}
+
+	newEncryptedKey, err := cryptoutils.EncryptPrivateKey(privateKey, newPassword, &config.DefaultArgon2Params)
+	if err != nil {
+		return err
+	}
+
+	requestBody := config.ChangePasswordRequestBody{
+		UserID:                  uid,
+		OldPassword:             oldPassword,
+		NewPassword:             newPassword,
+		EncryptedUserPrivateKey: newEncryptedKey.EncryptedUserPrivateKey,
+		PrivateKeySalt:          newEncryptedKey.PrivateKeySalt,
+		PrivateKeyNonce:         newEncryptedKey.PrivateKeyNonce,
+		ArgonParams:             newEncryptedKey.ArgonParams,
+	}
+	var responseBody config.ChangePasswordResponseBody
+	if err := app.HttpClient.Do(ctx, "POST", "/users/password", requestBody, &responseBody, true); err != nil {
+		return err
+	}
+
+	return config.SaveAccountKey(email, *newEncryptedKey)
+}
+
+// decryptAccountKey decrypts the private key stored at the last login with
+// password, which checks the password without creating a session.
+func decryptAccountKey(email, password string) (*config.EncryptedPrivateKey, *ecdh.PrivateKey, error) {
+	encryptedKey, err := config.LoadAccountKey(email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if encryptedKey == nil {
+		return nil, nil, errors.New("your encrypted private key is not stored locally; run 'envcrypt login' again")
+	}
+
+	argonParams := encryptedKey.ArgonParams.ParamsOrDefault()
+	if err := argonParams.Validate(); err != nil {
+		return nil, nil, err
+	}
+	decryptedPrivateKey, err := cryptoutils.DecryptPrivateKey(encryptedKey, password, &argonParams)
+	if err != nil {
+		return nil, nil, errors.New("password is incorrect (if you changed it on another device, run 'envcrypt login' again)")
+	}
+
+	privateKey, err := ecdh.X25519().NewPrivateKey(decryptedPrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known, err := config.LoadKnownKey(email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if known == nil || !bytes.Equal(privateKey.PublicKey().Bytes(), known.PublicKey) {
+		return nil, nil, errors.New("stored private key does not match the account's public key")
+	}
+
+	return encryptedKey, privateKey, nil
 }
 
 func (app *App) Logout(ctx context.Context, email string) error {
 	var errs []error
 
-	userId := viper.GetString("user.id")
+	if email == "" {
+		email = viper.GetString(config.ContextKey("user.email"))
+	}
+
+	userId := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(userId)
 	if err != nil {
 		return err
@@ -115,6 +291,14 @@ func (app *App) Logout(ctx context.Context, email string) error {
 		errs = append(errs, err)
 	}
 
+	if err := cryptoutils.DeleteSession(email); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := config.DeleteAccountKey(email); err != nil {
+		errs = append(errs, err)
+	}
+
 	if err := cryptoutils.RemoveUserEmail(); err != nil {
 		errs = append(errs, err)
 	}
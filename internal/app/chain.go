@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+)
+
+// ChainError reports a break in the version history of an environment: a
+// missing version, a version that does not follow its parent, or a head
+// older than one seen before.
+type ChainError struct {
+	EnvName string
+	Version int32
+	Msg     string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("history of %s is inconsistent at v%d: %s", e.EnvName, e.Version, e.Msg)
+}
+
+// verifyChain checks that versions form an unbroken chain. Versions pushed
+// before chaining have no PrevHash and are only accepted before the first
+// chained version.
+func verifyChain(envName string, versions []DecryptedEnvVersion) error {
+	sorted := make([]DecryptedEnvVersion, len(versions))
+	copy(sorted, versions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	chained := false
+	for i, v := range sorted {
+		if i > 0 && v.Version == sorted[i-1].Version {
+			return &ChainError{EnvName: envName, Version: v.Version, Msg: "the version was returned twice (history was forked)"}
+		}
+
+		if len(v.Metadata.PrevHash) == 0 {
+			if chained {
+				return &ChainError{EnvName: envName, Version: v.Version, Msg: "the version is not linked to its parent"}
+			}
+			continue
+		}
+		chained = true
+
+		// The parent of the oldest listed version is not available.
+		if i == 0 {
+			continue
+		}
+
+		prev := sorted[i-1]
+		if v.Version != prev.Version+1 {
+			return &ChainError{EnvName: envName, Version: v.Version, Msg: fmt.Sprintf("versions v%d to v%d are missing", prev.Version+1, v.Version-1)}
+		}
+		if !bytes.Equal(v.Metadata.PrevHash, prev.hash) {
+			return &ChainError{EnvName: envName, Version: v.Version, Msg: fmt.Sprintf("the parent does not match v%d (history was rewritten or forked)", prev.Version)}
+		}
+	}
+
+	return nil
+}
+
+// checkHead compares the head served for an environment with the newest one
+// seen before and records it. A server that rolls back or rewrites the head
+// is reported as a *ChainError.
+func (app *App) checkHead(access *projectAccess, envName string, head *DecryptedEnvVersion) error {
+	if access.ProjectName == "" {
+		return nil
+	}
+
+	state, err := config.LoadEnvState(access.ProjectName, envName)
+	if err != nil {
+		return err
+	}
+
+	if state != nil && state.HeadVersion > 0 {
+		switch {
+		case head.Version < state.HeadVersion:
+			return &ChainError{EnvName: envName, Version: head.Version, Msg: fmt.Sprintf("the server returned an older head than v%d seen before (rollback attack?)", state.HeadVersion)}
+		case head.Version == state.HeadVersion && len(state.HeadHash) > 0 && !bytes.Equal(head.hash, state.HeadHash):
+			return &ChainError{EnvName: envName, Version: head.Version, Msg: "the head differs from the one seen before (history was rewritten)"}
+		case head.Version == state.HeadVersion+1 && len(state.HeadHash) > 0 && len(head.Metadata.PrevHash) > 0 && !bytes.Equal(head.Metadata.PrevHash, state.HeadHash):
+			return &ChainError{EnvName: envName, Version: head.Version, Msg: fmt.Sprintf("parent does not match v%d seen before (history was forked)", state.HeadVersion)}
+		}
+	}
+
+	return app.recordHead(access, envName, head.Version, head.hash)
+}
+
+// checkMissingHead rejects an environment the server reports as having no
+// versions after a head was seen before, which would hide its history.
+func (app *App) checkMissingHead(access *projectAccess, envName string) error {
+	if access.ProjectName == "" {
+		return nil
+	}
+
+	state, err := config.LoadEnvState(access.ProjectName, envName)
+	if err != nil {
+		return err
+	}
+	if state != nil && state.HeadVersion > 0 {
+		return &ChainError{EnvName: envName, Version: state.HeadVersion, Msg: "the server no longer returns the environment, but this version was seen before (history was deleted?)"}
+	}
+	return nil
+}
+
+func (app *App) recordHead(access *projectAccess, envName string, version int32, hash []byte) error {
+	if access.ProjectName == "" {
+		return nil
+	}
+
+	return config.UpdateEnvState(access.ProjectName, envName, func(s *config.EnvState) {
+		if version >= s.HeadVersion {
+			s.HeadVersion = version
+			s.HeadHash = hash
+		}
+	})
+}
+
+// checkServedVersion rejects a version other than the one requested, which
+// a server could serve in its place.
+func checkServedVersion(envName string, requested *int32, served int32) error {
+	if requested != nil && served != *requested {
+		return &ChainError{EnvName: envName, Version: served, Msg: fmt.Sprintf("the server returned it when v%d was requested", *requested)}
+	}
+	return nil
+}
+
+// checkLegacyVersions rejects unsigned versions and unbound ciphertexts
+// unless they are history from before the first signed or bound version of
+// the environment, as far as this batch and earlier runs have seen. Chained
+// versions are always signed. The oldest signed and bound versions are
+// remembered, so later runs cannot be shown stripped signatures or replayed
+// ciphertexts instead.
+func (app *App) checkLegacyVersions(access *projectAccess, envName string, versions ...DecryptedEnvVersion) error {
+	var state config.EnvState
+	if access.ProjectName != "" {
+		s, err := config.LoadEnvState(access.ProjectName, envName)
+		if err != nil {
+			return err
+		}
+		if s != nil {
+			state = *s
+		}
+	}
+
+	firstSigned, firstBound := state.FirstSignedVersion, state.FirstBoundVersion
+	for _, v := range versions {
+		if v.signed && (firstSigned == 0 || v.Version < firstSigned) {
+			firstSigned = v.Version
+		}
+		if v.bound && (firstBound == 0 || v.Version < firstBound) {
+			firstBound = v.Version
+		}
+	}
+
+	for _, v := range versions {
+		if !v.bound && firstBound != 0 && v.Version > firstBound {
+			return &ChainError{EnvName: envName, Version: v.Version, Msg: fmt.Sprintf("its ciphertext is not bound to it, but v%d of this environment is (replayed version?)", firstBound)}
+		}
+
+		if v.signed {
+			continue
+		}
+		if len(v.Metadata.PrevHash) > 0 {
+			return &SignatureError{EnvName: envName, Version: v.Version, Reason: "it is chained to its parent but unsigned"}
+		}
+		if firstSigned != 0 && v.Version > firstSigned {
+			return &SignatureError{EnvName: envName, Version: v.Version, Reason: fmt.Sprintf("it is unsigned, but v%d of this environment is already signed", firstSigned)}
+		}
+		app.warnOnce("unsigned:"+envName, fmt.Sprintf("%s has unsigned versions from before signing (first seen: v%d); their authors cannot be verified", envName, v.Version))
+	}
+
+	if access.ProjectName == "" || (firstSigned == state.FirstSignedVersion && firstBound == state.FirstBoundVersion) {
+		return nil
+	}
+	return config.UpdateEnvState(access.ProjectName, envName, func(s *config.EnvState) {
+		if s.FirstSignedVersion == 0 || firstSigned < s.FirstSignedVersion {
+			s.FirstSignedVersion = firstSigned
+		}
+		if s.FirstBoundVersion == 0 || firstBound < s.FirstBoundVersion {
+			s.FirstBoundVersion = firstBound
+		}
+	})
+}
@@ -3,32 +3,76 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
 
+	"github.com/envcrypts/envcrypt-cli/internal/client"
 	"github.com/envcrypts/envcrypt-cli/internal/config"
 	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
-func (app *App) PushEnv(ctx context.Context, projectName, envName string, envMap map[string]string) error {
+// ErrHeadMoved is returned when another push landed between reading the
+// remote head and uploading the new version.
+var ErrHeadMoved = errors.New("remote head changed during push, please retry")
 
-	// Getting Local Information
-	userEmail, userId := viper.GetString("user.email"), viper.GetString("user.id")
+// PushConflictError is returned when the remote head moved since the version
+// a push was based on and the two sets of changes overlap.
+type PushConflictError struct {
+	BaseVersion int32 // 0 when the base is unknown
+	HeadVersion int32
+
+	Base  map[string]string
+	Local map[string]string
+	Head  map[string]string
+
+	Conflicts []string
+}
+
+func (e *PushConflictError) Error() string {
+	return fmt.Sprintf("remote head moved to v%d and %d key(s) conflict", e.HeadVersion, len(e.Conflicts))
+}
+
+type PushOptions struct {
+	// Force overwrites the remote head even if it moved since the base version.
+	Force bool
+	// Message describes the change, like a commit message.
+	Message string
+}
+
+type PushResult struct {
+	Version int32
+	// Merged is set when remote changes were merged into the pushed version,
+	// meaning the local file no longer matches the remote head.
+	Merged bool
+}
+
+// projectAccess holds everything needed to read and write the environments
+// of a project as the current user.
+type projectAccess struct {
+	// ProjectName is empty for service roles, which keep no local state.
+	ProjectName string
+
+	UserId    uuid.UUID
+	Email     string
+	ProjectId uuid.UUID
+	PMK       []byte
+}
+
+func (app *App) unlockProject(ctx context.Context, projectName string) (*projectAccess, error) {
+	userEmail, userId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
 	if userEmail == "" || userId == "" {
-		return errors.New("missing user email or user id")
+		return nil, errors.New("missing user email or user id")
 	}
 
 	uid, err := uuid.Parse(userId)
 	if err != nil {
-		return err
-	}
-
-	privateKey, err := cryptoutils.LoadPrivateKey(userEmail)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Get Project ID
 	projectRequest := config.GetMemberProjectRequest{
 		ProjectName: projectName,
 		UserId:      uid,
@@ -37,7 +81,7 @@ func (app *App) PushEnv(ctx context.Context, projectName, envName string, envMap
 	var projectResponse config.GetMemberProjectResponse
 	err = app.HttpClient.Do(ctx, "POST", "/projects/get", projectRequest, &projectResponse, true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	wrappedKey := &cryptoutils.WrappedKey{
@@ -46,160 +90,414 @@ func (app *App) PushEnv(ctx context.Context, projectName, envName string, envMap
 		WrapEphemeralPub: projectResponse.EphemeralPublicKey,
 	}
 
-	data, err := cryptoutils.PrepareEnvForStorage(envMap)
+	pmk, err := app.unwrapPMK(userEmail, wrappedKey)
 	if err != nil {
-		return errors.New("could not prepare environment variables")
+		return nil, err
 	}
 
-	pmk, err := cryptoutils.UnwrapPMK(wrappedKey, privateKey)
-	if err != nil {
-		return errors.New("could not unwrap private key")
+	return &projectAccess{
+		ProjectName: projectName,
+		UserId:      uid,
+		Email:       userEmail,
+		ProjectId:   projectResponse.ProjectId,
+		PMK:         pmk,
+	}, nil
+}
+
+// fetchEnv downloads and decrypts a version of an environment, or the head
+// when version is nil. It returns nil if the environment has no versions.
+func (app *App) fetchEnv(ctx context.Context, access *projectAccess, envName string, version *int32) (*DecryptedEnvVersion, error) {
+	envRequest := config.GetEnvRequest{
+		ProjectId: access.ProjectId,
+		Email:     access.Email,
+		EnvName:   envName,
+		Version:   version,
 	}
 
-	// encrypt using pmk and store the nonce, ciphertext
-	encryptedData, nonce, err := cryptoutils.EncryptENV(pmk, data)
+	var envResponse config.GetEnvResponse
+	err := app.HttpClient.Do(ctx, "POST", "/env/search", envRequest, &envResponse, true)
 	if err != nil {
-		return errors.New("could not encrypt data")
+		var httpErr *client.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			if version == nil {
+				return nil, app.checkMissingHead(access, envName)
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := checkServedVersion(envName, version, envResponse.Version); err != nil {
+		return nil, err
 	}
 
-	metadata := config.Metadata{
-		Type: "env_created",
+	env, _, err := app.openEnv(ctx, access, envName, config.EnvResponse(envResponse))
+	if err != nil {
+		return nil, err
 	}
-	createRequest := config.AddEnvRequest{
-		ProjectId:  projectResponse.ProjectId,
-		UserId:     uid,
-		EnvName:    envName,
-		CipherText: encryptedData,
-		Nonce:      nonce,
-		Metadata:   metadata,
+	if err := app.checkLegacyVersions(access, envName, *env); err != nil {
+		return nil, err
 	}
 
-	var createResponse config.AddEnvResponse
-	if err := app.HttpClient.Do(ctx, "POST", "/env/create", createRequest, &createResponse, true); err != nil {
-		return err
+	if version == nil {
+		if err := app.checkHead(access, envName, env); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return env, nil
 }
 
-func (app *App) PullEnv(ctx context.Context, projectName, envName string) (map[string]string, error) {
+// openEnv decrypts a version, verifies its signature and parses it. It also
+// returns the decrypted storage bytes.
+func (app *App) openEnv(ctx context.Context, access *projectAccess, envName string, resp config.EnvResponse) (*DecryptedEnvVersion, []byte, error) {
+	ad := cryptoutils.EnvAssociatedData(access.ProjectId, envName, resp.Version)
+	data, format, err := cryptoutils.OpenEnv(access.PMK, resp.CipherText, resp.Nonce, ad)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decrypt %s v%d; it may belong to another environment or version", envName, resp.Version)
+	}
+	if format != cryptoutils.EnvFormatEnvelope {
+		app.warnOnce("legacy:"+envName, fmt.Sprintf("%s has versions in an older encryption format (first seen: v%d); 'envcrypt migrate' upgrades them", envName, resp.Version))
+	}
 
-	userEmail, userId := viper.GetString("user.email"), viper.GetString("user.id")
-	if userEmail == "" || userId == "" {
-		return nil, errors.New("missing user email or user id")
+	if err := app.verifyVersion(ctx, access.ProjectId, envName, resp, data); err != nil {
+		return nil, nil, err
 	}
 
-	uid, err := uuid.Parse(userId)
+	envMap, err := cryptoutils.ReadCompressedEnv(data)
 	if err != nil {
-		return nil, err
+		return nil, nil, errors.New("could not parse environment variables")
 	}
 
-	userPriv, err := cryptoutils.LoadPrivateKey(userEmail)
+	return &DecryptedEnvVersion{
+		Version:  resp.Version,
+		Metadata: resp.Metadata,
+		Env:      envMap,
+		hash:     cryptoutils.VersionHash(access.ProjectId, envName, resp.Metadata, data),
+		signed:   len(resp.Signature) > 0,
+		bound:    format.Bound(),
+	}, data, nil
+}
+
+// PushEnv uploads envMap as a new version. The push is based on the version
+// recorded by the last pull or push of this environment; if the remote head
+// moved since, non-overlapping changes are merged and overlapping ones are
+// reported as a *PushConflictError unless opts.Force is set.
+func (app *App) PushEnv(ctx context.Context, projectName, envName string, envMap map[string]string, opts PushOptions) (*PushResult, error) {
+	state, err := config.LoadEnvState(projectName, envName)
 	if err != nil {
 		return nil, err
 	}
 
-	projectRequest := config.GetMemberProjectRequest{
-		ProjectName: projectName,
-		UserId:      uid,
+	var base *int32
+	if state != nil && state.BaseVersion != 0 {
+		base = &state.BaseVersion
 	}
 
-	var projectResponse config.GetMemberProjectResponse
-	err = app.HttpClient.Do(ctx, "POST", "/projects/get", projectRequest, &projectResponse, true)
+	metadata := config.Metadata{Type: "env_created", Message: opts.Message}
+	result, err := app.pushEnv(ctx, projectName, envName, envMap, metadata, base, opts.Force)
 	if err != nil {
 		return nil, err
 	}
 
-	envRequest := config.GetEnvRequest{
-		ProjectId: projectResponse.ProjectId,
-		Email:     userEmail,
-		EnvName:   envName,
-		Version:   nil,
+	// After a merge the local file lacks the remote changes, so it is still
+	// based on the old version.
+	if !result.Merged {
+		if err := app.SetBaseVersion(projectName, envName, result.Version); err != nil {
+			return nil, err
+		}
 	}
 
-	var envResponse config.GetEnvResponse
-	err = app.HttpClient.Do(ctx, "POST", "/env/search", envRequest, &envResponse, true)
+	return result, nil
+}
+
+func (app *App) pushEnv(
+	ctx context.Context,
+	projectName, envName string,
+	envMap map[string]string,
+	metadata config.Metadata,
+	base *int32,
+	force bool,
+) (*PushResult, error) {
+
+	access, err := app.unlockProject(ctx, projectName)
 	if err != nil {
 		return nil, err
 	}
 
-	// Getting Wrapped Keys
-	keyRequest := config.GetMemberProjectRequest{
-		ProjectName: projectName,
-		UserId:      uid,
+	head, err := app.fetchEnv(ctx, access, envName, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	var keyResponse config.GetMemberProjectResponse
-	if err := app.HttpClient.Do(ctx, "POST", "/projects/get", keyRequest, &keyResponse, true); err != nil {
-		return nil, errors.New("could not get project keys")
+	result := &PushResult{Version: 1}
+
+	if head != nil {
+		result.Version = head.Version + 1
+
+		moved := base == nil || *base != head.Version
+		if moved && !force {
+			baseMap := map[string]string{}
+			var baseVersion int32
+			if base != nil {
+				baseVersion = *base
+				baseEnv, err := app.fetchEnv(ctx, access, envName, base)
+				if err != nil {
+					return nil, err
+				}
+				if baseEnv != nil {
+					baseMap = baseEnv.Env
+				}
+			}
+
+			merged, conflicts := cryptoutils.MergeEnvVersions(baseMap, envMap, head.Env)
+			if len(conflicts) > 0 {
+				return nil, &PushConflictError{
+					BaseVersion: baseVersion,
+					HeadVersion: head.Version,
+					Base:        baseMap,
+					Local:       envMap,
+					Head:        head.Env,
+					Conflicts:   conflicts,
+				}
+			}
+
+			diff := cryptoutils.DiffEnvVersions(envMap, merged)
+			result.Merged = len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Modified) > 0
+			envMap = merged
+		}
 	}
 
-	wrappedKey := &cryptoutils.WrappedKey{
-		WrappedPMK:       keyResponse.WrappedPMK,
-		WrapNonce:        keyResponse.WrapNonce,
-		WrapEphemeralPub: keyResponse.EphemeralPublicKey,
+	data, err := cryptoutils.PrepareEnvForStorage(envMap)
+	if err != nil {
+		return nil, errors.New("could not prepare environment variables")
 	}
 
-	pmk, err := cryptoutils.UnwrapPMK(wrappedKey, userPriv)
+	version, err := app.createVersion(ctx, access, envName, data, metadata, head)
 	if err != nil {
-		return nil, errors.New("could not unwrap private key")
+		return nil, err
 	}
 
-	envBytes, err := cryptoutils.DecryptENV(pmk, envResponse.CipherText, envResponse.Nonce)
+	if version != 0 {
+		result.Version = version
+	}
+
+	return result, nil
+}
+
+// createVersion signs, encrypts and uploads data as a new version on top of
+// parent. It returns the version number assigned by the server, or 0 if the
+// server did not report one.
+func (app *App) createVersion(
+	ctx context.Context,
+	access *projectAccess,
+	envName string,
+	data []byte,
+	metadata config.Metadata,
+	parent *DecryptedEnvVersion,
+) (int32, error) {
+
+	// Signatures cover the time in whole seconds, which survives any
+	// precision the server stores it with.
+	metadata.Author = access.Email
+	metadata.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	// Commit to the parent so the server cannot drop or rewrite it unnoticed.
+	var parentVersion *int32
+	if parent != nil {
+		parentVersion = &parent.Version
+		metadata.PrevHash = parent.hash
+	}
+
+	signature, err := app.signVersion(access.Email, cryptoutils.VersionSigningMessage(access.ProjectId, envName, metadata, data))
 	if err != nil {
-		return nil, errors.New("could not decrypt data")
+		return 0, fmt.Errorf("could not sign version: %w", err)
 	}
 
-	envMap, err := cryptoutils.ReadCompressedEnv(envBytes)
+	// The server numbers versions consecutively; a push that does not land
+	// right after parent is rejected with 409.
+	version := int32(1)
+	if parent != nil {
+		version = parent.Version + 1
+	}
+
+	// encrypt using pmk and store the nonce, ciphertext
+	ad := cryptoutils.EnvAssociatedData(access.ProjectId, envName, version)
+	encryptedData, nonce, err := cryptoutils.SealEnv(access.PMK, data, ad)
 	if err != nil {
-		return nil, errors.New("could not parse environment variables")
+		return 0, errors.New("could not encrypt data")
 	}
 
-	return envMap, nil
-}
+	createRequest := config.AddEnvRequest{
+		ProjectId:     access.ProjectId,
+		UserId:        access.UserId,
+		EnvName:       envName,
+		CipherText:    encryptedData,
+		Nonce:         nonce,
+		Metadata:      metadata,
+		ParentVersion: parentVersion,
+		Signature:     signature,
+	}
 
-type DecryptedEnvVersion struct {
-	Version  int32
-	Metadata config.Metadata
-	Env      map[string]string
+	var createResponse config.AddEnvResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/env/create", createRequest, &createResponse, true); err != nil {
+		var httpErr *client.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusConflict {
+			return 0, ErrHeadMoved
+		}
+		return 0, err
+	}
+
+	// The ciphertext is bound to version; stored under another number it
+	// could never be decrypted.
+	if createResponse.Version != 0 && createResponse.Version != version {
+		return 0, fmt.Errorf("the server stored %s as v%d instead of v%d", envName, createResponse.Version, version)
+	}
+	pushed := DecryptedEnvVersion{
+		Version:  version,
+		Metadata: metadata,
+		hash:     cryptoutils.VersionHash(access.ProjectId, envName, metadata, data),
+		signed:   true,
+		bound:    true,
+	}
+	if err := app.checkLegacyVersions(access, envName, pushed); err != nil {
+		return 0, err
+	}
+	if err := app.recordHead(access, envName, pushed.Version, pushed.hash); err != nil {
+		return 0, err
+	}
+
+	return version, nil
 }
 
-func (app *App) PullAllEnv(ctx context.Context, projectName, envName string) ([]DecryptedEnvVersion, error) {
+// SetBaseVersion records the version the local env file now corresponds to.
+func (app *App) SetBaseVersion(projectName, envName string, version int32) error {
+	return config.UpdateEnvState(projectName, envName, func(s *config.EnvState) {
+		s.BaseVersion = version
+	})
+}
 
-	userEmail, userId := viper.GetString("user.email"), viper.GetString("user.id")
-	if userEmail == "" || userId == "" {
-		return nil, errors.New("missing user email or user id")
+func (app *App) PullEnv(ctx context.Context, projectName, envName string) (map[string]string, error) {
+	env, err := app.PullEnvVersion(ctx, projectName, envName, nil)
+	if err != nil {
+		return nil, err
 	}
-	uid, err := uuid.Parse(userId)
+
+	return env.Env, nil
+}
+
+// PullEnvVersion downloads and decrypts a version of an environment, or the
+// head when version is nil.
+func (app *App) PullEnvVersion(ctx context.Context, projectName, envName string, version *int32) (*DecryptedEnvVersion, error) {
+	access, err := app.unlockProject(ctx, projectName)
 	if err != nil {
 		return nil, err
 	}
 
-	userPriv, err := cryptoutils.LoadPrivateKey(userEmail)
+	env, err := app.fetchEnv(ctx, access, envName, version)
 	if err != nil {
 		return nil, err
 	}
+	if env == nil {
+		return nil, errors.New("no versions found for this environment")
+	}
 
-	projectRequest := config.GetMemberProjectRequest{
-		ProjectName: projectName,
-		UserId:      uid,
+	return env, nil
+}
+
+// SetEnvKeys decrypts the latest version, applies values on top of it and
+// pushes the result as a new version.
+func (app *App) SetEnvKeys(ctx context.Context, projectName, envName string, values map[string]string, message string) error {
+	access, err := app.unlockProject(ctx, projectName)
+	if err != nil {
+		return err
 	}
-	var projectResponse config.GetMemberProjectResponse
-	err = app.HttpClient.Do(ctx, "POST", "/projects/get", projectRequest, &projectResponse, true)
+
+	head, err := app.fetchEnv(ctx, access, envName, nil)
+	if err != nil {
+		return err
+	}
+
+	envMap := make(map[string]string, len(values))
+	var base *int32
+	if head != nil {
+		envMap = head.Env
+		base = &head.Version
+	}
+
+	for k, v := range values {
+		envMap[k] = v
+	}
+
+	_, err = app.pushEnv(ctx, projectName, envName, envMap, config.Metadata{Type: "key_set", Message: message}, base, false)
+	return err
+}
+
+// UnsetEnvKeys decrypts the latest version, removes keys from it and pushes
+// the result as a new version. It returns the keys that were not present.
+func (app *App) UnsetEnvKeys(ctx context.Context, projectName, envName string, keys []string, message string) ([]string, error) {
+	access, err := app.unlockProject(ctx, projectName)
 	if err != nil {
 		return nil, err
 	}
 
-	wrappedKey := &cryptoutils.WrappedKey{
-		WrappedPMK:       projectResponse.WrappedPMK,
-		WrapNonce:        projectResponse.WrapNonce,
-		WrapEphemeralPub: projectResponse.EphemeralPublicKey,
+	head, err := app.fetchEnv(ctx, access, envName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, errors.New("no versions found for this environment")
+	}
+
+	envMap := head.Env
+	var missing []string
+	removed := 0
+	for i, k := range keys {
+		if slices.Contains(keys[:i], k) {
+			continue
+		}
+		if _, ok := envMap[k]; !ok {
+			missing = append(missing, k)
+			continue
+		}
+		delete(envMap, k)
+		removed++
+	}
+
+	if removed == 0 {
+		return missing, errors.New("none of the given keys exist")
+	}
+
+	if _, err := app.pushEnv(ctx, projectName, envName, envMap, config.Metadata{Type: "key_unset", Message: message}, &head.Version, false); err != nil {
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+type DecryptedEnvVersion struct {
+	Version  int32
+	Metadata config.Metadata
+	Env      map[string]string
+
+	// hash identifies the version in the chain of its environment.
+	hash []byte
+	// signed is set when the version carried a valid signature, bound when
+	// its ciphertext was tied to its environment and version.
+	signed bool
+	bound  bool
+}
+
+func (app *App) PullAllEnv(ctx context.Context, projectName, envName string) ([]DecryptedEnvVersion, error) {
+
+	access, err := app.unlockProject(ctx, projectName)
+	if err != nil {
+		return nil, err
 	}
 
 	envRequest := config.GetEnvVersionsRequest{
-		ProjectId: projectResponse.ProjectId,
+		ProjectId: access.ProjectId,
 		EnvName:   envName,
-		Email:     userEmail,
+		Email:     access.Email,
 	}
 	var envResponse config.GetEnvVersionsResponse
 	err = app.HttpClient.Do(ctx, "POST", "/env/search/all", envRequest, &envResponse, true)
@@ -207,62 +505,64 @@ func (app *App) PullAllEnv(ctx context.Context, projectName, envName string) ([]
 		return nil, err
 	}
 
-	pmk, err := cryptoutils.UnwrapPMK(wrappedKey, userPriv)
-	if err != nil {
-		return nil, errors.New("could not unwrap private key")
-	}
-
 	envs := make([]DecryptedEnvVersion, len(envResponse.EnvVersions))
 
 	for i, ver := range envResponse.EnvVersions {
-		envMapBytes, err := cryptoutils.DecryptENV(pmk, ver.CipherText, ver.Nonce)
+		env, _, err := app.openEnv(ctx, access, envName, ver)
 		if err != nil {
-			return nil, errors.New("could not decrypt data")
+			return nil, err
 		}
+		envs[i] = *env
+	}
 
-		envMap, err := cryptoutils.ReadCompressedEnv(envMapBytes)
-		if err != nil {
-			return nil, errors.New("could not parse environment variables")
-		}
+	if err := app.checkLegacyVersions(access, envName, envs...); err != nil {
+		return nil, err
+	}
+	if err := verifyChain(envName, envs); err != nil {
+		return nil, err
+	}
 
-		envs[i] = DecryptedEnvVersion{
-			Version:  ver.Version,
-			Metadata: ver.Metadata,
-			Env:      envMap,
+	var head *DecryptedEnvVersion
+	for i := range envs {
+		if head == nil || envs[i].Version > head.Version {
+			head = &envs[i]
+		}
+	}
+	if head != nil {
+		if err := app.checkHead(access, envName, head); err != nil {
+			return nil, err
 		}
 	}
 
 	return envs, nil
 }
 
+// RollbackEnv pushes the contents of an old version as a new version. The
+// old version is verified, then re-encrypted for its new version number and
+// signed again by the current user.
 func (app *App) RollbackEnv(ctx context.Context, projectName, envName string, version *int32) error {
-
-	// Getting Local Information
-	userEmail, userId := viper.GetString("user.email"), viper.GetString("user.id")
-	if userEmail == "" || userId == "" {
-		return errors.New("missing user email or user id")
+	if version == nil {
+		return errors.New("a version to roll back to is required")
 	}
 
-	uid, err := uuid.Parse(userId)
+	access, err := app.unlockProject(ctx, projectName)
 	if err != nil {
 		return err
 	}
 
-	// Get Project ID
-	projectRequest := config.GetMemberProjectRequest{
-		ProjectName: projectName,
-		UserId:      uid,
-	}
-	var projectResponse config.GetMemberProjectResponse
-	err = app.HttpClient.Do(ctx, "POST", "/projects/get", projectRequest, &projectResponse, true)
+	// Get the current head so the rollback is recorded against it
+	head, err := app.fetchEnv(ctx, access, envName, nil)
 	if err != nil {
 		return err
 	}
+	if head == nil {
+		return errors.New("no versions found for this environment")
+	}
 
 	// Get the ENV for rollback
 	envRequest := config.GetEnvRequest{
-		ProjectId: projectResponse.ProjectId,
-		Email:     userEmail,
+		ProjectId: access.ProjectId,
+		Email:     access.Email,
 		EnvName:   envName,
 		Version:   version,
 	}
@@ -271,23 +571,23 @@ func (app *App) RollbackEnv(ctx context.Context, projectName, envName string, ve
 	if err != nil {
 		return err
 	}
-
-	// Push the rollback env
-	metadata := config.Metadata{
-		Type: "env_rollback",
+	if err := checkServedVersion(envName, version, envResponse.Version); err != nil {
+		return err
 	}
-	createRequest := config.AddEnvRequest{
-		ProjectId:  projectResponse.ProjectId,
-		UserId:     uid,
-		EnvName:    envName,
-		CipherText: envResponse.CipherText,
-		Nonce:      envResponse.Nonce,
-		Metadata:   metadata,
+
+	target, data, err := app.openEnv(ctx, access, envName, config.EnvResponse(envResponse))
+	if err != nil {
+		return err
 	}
-	var createResponse config.AddEnvResponse
-	if err := app.HttpClient.Do(ctx, "POST", "/env/create", createRequest, &createResponse, true); err != nil {
+	if err := app.checkLegacyVersions(access, envName, *target); err != nil {
 		return err
 	}
 
-	return nil
+	// Push the rollback env
+	metadata := config.Metadata{
+		Type:    "env_rollback",
+		Message: fmt.Sprintf("Rollback to v%d", target.Version),
+	}
+	_, err = app.createVersion(ctx, access, envName, data, metadata, head)
+	return err
 }
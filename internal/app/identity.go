@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/spf13/viper"
+)
+
+// LocalKeys are the public halves of the key pair held in the keystore.
+type LocalKeys struct {
+	Email            string
+	PublicKey        []byte
+	SigningPublicKey []byte
+}
+
+// LocalKeys derives the public keys of the logged in user from the private
+// key, asking the agent first when one is running.
+func (app *App) LocalKeys() (*LocalKeys, error) {
+	email := viper.GetString(config.ContextKey("user.email"))
+	if email == "" {
+		return nil, errors.New("user not authenticated")
+	}
+
+	if app.Agent != nil {
+		publicKey, signingKey, err := app.Agent.PublicKeys(config.KeyringService(), email)
+		if err == nil {
+			return &LocalKeys{
+				Email:            email,
+				PublicKey:        publicKey,
+				SigningPublicKey: signingKey,
+			}, nil
+		}
+		// Without the key, or as an older agent, fall back to the keystore.
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return nil, fmt.Errorf("could not read your private key: %w", err)
+	}
+
+	publicKey, err := cryptoutils.PublicKeyFromPrivate(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := cryptoutils.SigningPublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalKeys{
+		Email:            email,
+		PublicKey:        publicKey,
+		SigningPublicKey: signingKey,
+	}, nil
+}
+
+// PublishedKeyMatches reports whether the server publishes the local public
+// and signing keys for the account. It does not after registering again
+// elsewhere, when the keystore still holds the old key.
+func (app *App) PublishedKeyMatches(ctx context.Context, keys *LocalKeys) (bool, error) {
+	userReq := config.UserKeyRequestBody{Email: keys.Email}
+	var userResp config.UserKeyResponseBody
+	if err := app.HttpClient.Do(ctx, "POST", "/users/search", userReq, &userResp, false); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(userResp.PublicKey, keys.PublicKey) &&
+		bytes.Equal(userResp.SigningPublicKey, keys.SigningPublicKey), nil
+}
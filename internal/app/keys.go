@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/envcrypts/envcrypt-cli/internal/agent"
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/google/uuid"
+)
+
+// unwrapPMK opens a project key wrapped to the user. With an agent running,
+// the agent does the unwrapping and caches the result; the private key is
+// only read from the keystore when the agent does not hold it yet.
+func (app *App) unwrapPMK(email string, wrapped *cryptoutils.WrappedKey) ([]byte, error) {
+	if app.Agent != nil {
+		pmk, err := app.unwrapWithAgent(email, wrapped)
+		if !agent.IsUnavailable(err) {
+			return pmk, err
+		}
+		// The agent is gone; fall back to the keystore.
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return nil, err
+	}
+
+	pmk, err := cryptoutils.UnwrapPMK(wrapped, privateKey)
+	if err != nil {
+		return nil, errors.New("could not unwrap private key")
+	}
+	return pmk, nil
+}
+
+func (app *App) unwrapWithAgent(email string, wrapped *cryptoutils.WrappedKey) ([]byte, error) {
+	service := config.KeyringService()
+
+	pmk, err := app.Agent.Unwrap(service, email, wrapped)
+	if !errors.Is(err, agent.ErrNoKey) {
+		return pmk, err
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.Agent.AddKey(service, email, privateKey); err != nil {
+		return nil, err
+	}
+
+	return app.Agent.Unwrap(service, email, wrapped)
+}
+
+// SignatureError is returned when a version's signature does not match the
+// signing key of its author, or when a version that must be signed is not.
+type SignatureError struct {
+	EnvName string
+	Version int32
+	Author  string
+
+	// Reason replaces the invalid signature message when set.
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s v%d cannot be trusted: %s", e.EnvName, e.Version, e.Reason)
+	}
+	return fmt.Sprintf("%s v%d has an invalid signature for %s; it may have been tampered with", e.EnvName, e.Version, e.Author)
+}
+
+// signVersion signs a version message as the user, through the agent when
+// it holds the private key.
+func (app *App) signVersion(email string, msg []byte) ([]byte, error) {
+	if app.Agent != nil {
+		signature, err := app.Agent.Sign(config.KeyringService(), email, msg)
+		if !agent.IsUnavailable(err) && !errors.Is(err, agent.ErrNoKey) {
+			return signature, err
+		}
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return nil, err
+	}
+	return cryptoutils.SignMessage(privateKey, msg)
+}
+
+// verifyVersion checks the signature of a decrypted version against the
+// signing key of its author. Unsigned versions pass here; whether they may
+// be unsigned is decided by checkLegacyVersions.
+func (app *App) verifyVersion(ctx context.Context, projectId uuid.UUID, envName string, resp config.EnvResponse, data []byte) error {
+	author := resp.Metadata.Author
+
+	if len(resp.Signature) == 0 {
+		return nil
+	}
+	if author == "" {
+		return &SignatureError{EnvName: envName, Version: resp.Version, Author: "an unknown author"}
+	}
+
+	publicKey, err := app.signingKey(ctx, author)
+	if err != nil {
+		return err
+	}
+	if len(publicKey) == 0 {
+		return &SignatureError{EnvName: envName, Version: resp.Version, Author: author, Reason: fmt.Sprintf("no signing key is known for %s", author)}
+	}
+
+	msg := cryptoutils.VersionSigningMessage(projectId, envName, resp.Metadata, data)
+	if !cryptoutils.VerifyMessage(publicKey, msg, resp.Signature) {
+		return &SignatureError{EnvName: envName, Version: resp.Version, Author: author}
+	}
+	return nil
+}
+
+// signingKey returns the signing key of a user, cached for the lifetime of
+// the App. A pinned key always wins over what the server returns.
+func (app *App) signingKey(ctx context.Context, email string) ([]byte, error) {
+	if key, ok := app.signingKeys[email]; ok {
+		return key, nil
+	}
+
+	userResp, err := app.lookupUser(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("could not look up the signing key of %s: %w", email, err)
+	}
+
+	key := userResp.SigningPublicKey
+	known, err := config.LoadKnownKey(email)
+	if err != nil {
+		return nil, err
+	}
+	if known != nil {
+		if len(known.SigningPublicKey) == 0 && len(key) > 0 {
+			return nil, &UnverifiedKeyError{Email: email}
+		}
+		key = known.SigningPublicKey
+	}
+
+	app.signingKeys[email] = key
+	return key, nil
+}
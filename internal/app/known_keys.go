@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/spf13/viper"
+)
+
+// KeyChangedError is returned when the server hands out a different key for
+// a user than the one pinned locally. Either the user registered again or
+// the server is substituting its own key.
+type KeyChangedError struct {
+	Email   string
+	Pinned  []byte
+	Current []byte
+}
+
+func (e *KeyChangedError) Error() string {
+	return fmt.Sprintf("the key of %s changed from %s to %s; confirm the change with them and run 'envcrypt verify %s'",
+		e.Email, cryptoutils.Fingerprint(e.Pinned), cryptoutils.Fingerprint(e.Current), e.Email)
+}
+
+// lookupUser fetches a user's published keys and checks them against the
+// keys pinned for that email.
+func (app *App) lookupUser(ctx context.Context, email string) (*config.UserKeyResponseBody, error) {
+	userReq := config.UserKeyRequestBody{Email: email}
+	var userResp config.UserKeyResponseBody
+	if err := app.HttpClient.Do(ctx, "POST", "/users/search", userReq, &userResp, false); err != nil {
+		return nil, err
+	}
+
+	if err := app.checkKnownKey(email, userResp.PublicKey, userResp.SigningPublicKey); err != nil {
+		return nil, err
+	}
+	return &userResp, nil
+}
+
+// UnverifiedKeyError is returned when a user published a signing key after
+// their key was pinned. It is only trusted once compared with
+// 'envcrypt verify'.
+type UnverifiedKeyError struct {
+	Email string
+}
+
+func (e *UnverifiedKeyError) Error() string {
+	return fmt.Sprintf("the signing key of %s is new since their key was pinned; compare it with 'envcrypt verify %s'", e.Email, e.Email)
+}
+
+// checkMemberKey checks a member key the server returned in a listing
+// against the key pinned for that member, pinning both keys on first use.
+func (app *App) checkMemberKey(ctx context.Context, email string, publicKey []byte) error {
+	user, err := app.lookupUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(user.PublicKey, publicKey) {
+		return &KeyChangedError{Email: email, Pinned: user.PublicKey, Current: publicKey}
+	}
+	return nil
+}
+
+// checkKnownKey pins publicKey and signingKey for email on first use and
+// rejects keys that differ from the pinned ones. A signing key published
+// after the pin is not pinned here; see UnverifiedKeyError.
+func (app *App) checkKnownKey(email string, publicKey, signingKey []byte) error {
+	known, err := config.LoadKnownKey(email)
+	if err != nil {
+		return err
+	}
+
+	if known == nil {
+		if email != viper.GetString(config.ContextKey("user.email")) {
+			app.warn(fmt.Sprintf("Trusting the key of %s (%s) on first use; compare it with 'envcrypt verify %s'", email, cryptoutils.Fingerprint(publicKey), email))
+		}
+		return config.SaveKnownKey(email, config.KnownKey{
+			PublicKey:        publicKey,
+			SigningPublicKey: signingKey,
+			FirstSeen:        time.Now().UTC(),
+		})
+	}
+
+	if !bytes.Equal(known.PublicKey, publicKey) {
+		return &KeyChangedError{Email: email, Pinned: known.PublicKey, Current: publicKey}
+	}
+
+	if len(signingKey) == 0 || len(known.SigningPublicKey) == 0 {
+		return nil
+	}
+	if !bytes.Equal(known.SigningPublicKey, signingKey) {
+		return &KeyChangedError{Email: email, Pinned: known.SigningPublicKey, Current: signingKey}
+	}
+	return nil
+}
+
+// pinOwnKey records the user's own keys, which are known from the private
+// key rather than the server.
+func pinOwnKey(email string, privateKey []byte) error {
+	publicKey, err := cryptoutils.PublicKeyFromPrivate(privateKey)
+	if err != nil {
+		return err
+	}
+	signingKey, err := cryptoutils.SigningPublicKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	return config.SaveKnownKey(email, config.KnownKey{
+		PublicKey:        publicKey,
+		SigningPublicKey: signingKey,
+		FirstSeen:        time.Now().UTC(),
+		Verified:         true,
+	})
+}
+
+// KeyVerification is what a user compares with a colleague to make sure
+// the server handed out the colleague's real key.
+type KeyVerification struct {
+	Email        string
+	Fingerprint  string
+	SafetyNumber string
+
+	// Pinned is the key pinned before, or nil on first use. Changed is set
+	// when it differs from the key the server returns now, NewSigningKey
+	// when a signing key was published since the key was pinned.
+	Pinned        *config.KnownKey
+	Changed       bool
+	NewSigningKey bool
+
+	publicKey  []byte
+	signingKey []byte
+}
+
+// VerifyUser fetches the current key of email without pinning it and
+// computes the safety number shared with the local user.
+func (app *App) VerifyUser(ctx context.Context, email string) (*KeyVerification, error) {
+	ownEmail := viper.GetString(config.ContextKey("user.email"))
+	if ownEmail == "" {
+		return nil, errors.New("user not authenticated")
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(ownEmail)
+	if err != nil {
+		return nil, err
+	}
+	ownKey, err := cryptoutils.PublicKeyFromPrivate(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	ownSigningKey, err := cryptoutils.SigningPublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	userReq := config.UserKeyRequestBody{Email: email}
+	var userResp config.UserKeyResponseBody
+	if err := app.HttpClient.Do(ctx, "POST", "/users/search", userReq, &userResp, false); err != nil {
+		return nil, fmt.Errorf("could not look up %s: %w", email, err)
+	}
+
+	pinned, err := config.LoadKnownKey(email)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := pinned != nil && (!bytes.Equal(pinned.PublicKey, userResp.PublicKey) ||
+		len(pinned.SigningPublicKey) > 0 && !bytes.Equal(pinned.SigningPublicKey, userResp.SigningPublicKey))
+	newSigningKey := pinned != nil && len(pinned.SigningPublicKey) == 0 && len(userResp.SigningPublicKey) > 0
+
+	return &KeyVerification{
+		Email:       email,
+		Fingerprint: cryptoutils.Fingerprint(userResp.PublicKey),
+		SafetyNumber: cryptoutils.SafetyNumber(
+			ownEmail, ownKey, ownSigningKey,
+			email, userResp.PublicKey, userResp.SigningPublicKey,
+		),
+		Pinned:        pinned,
+		Changed:       changed,
+		NewSigningKey: newSigningKey,
+		publicKey:     userResp.PublicKey,
+		signingKey:    userResp.SigningPublicKey,
+	}, nil
+}
+
+// TrustKey pins the key shown in v as verified, replacing any earlier pin.
+func (app *App) TrustKey(v *KeyVerification) error {
+	firstSeen := time.Now().UTC()
+	if v.Pinned != nil && !v.Changed && !v.NewSigningKey {
+		firstSeen = v.Pinned.FirstSeen
+	}
+
+	delete(app.signingKeys, v.Email)
+	return config.SaveKnownKey(v.Email, config.KnownKey{
+		PublicKey:        v.publicKey,
+		SigningPublicKey: v.signingKey,
+		FirstSeen:        firstSeen,
+		Verified:         true,
+	})
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// MigrateKeystore re-encrypts local keystore entries in the current format
+// and returns how many were upgraded.
+func (app *App) MigrateKeystore() (int, error) {
+	return cryptoutils.UpgradeKeystore()
+}
+
+// MigrateAccountKey re-encrypts the private key stored on the server if it
+// uses an older format or weaker Argon2id parameters. It reports whether
+// anything was uploaded.
+func (app *App) MigrateAccountKey(ctx context.Context, password string) (bool, error) {
+	email, userId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
+	uid, err := uuid.Parse(userId)
+	if err != nil || email == "" {
+		return false, errors.New("user not authenticated")
+	}
+
+	encryptedKey, privateKey, err := decryptAccountKey(email, password)
+	if err != nil {
+		return false, err
+	}
+
+	argonParams := encryptedKey.ArgonParams.ParamsOrDefault()
+	if cryptoutils.IsEnvelope(encryptedKey.EncryptedUserPrivateKey) && !argonParams.WeakerThan(config.DefaultArgon2Params) {
+		return false, nil
+	}
+
+	if err := app.upgradePrivateKey(ctx, email, uid, privateKey.Bytes(), password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MigrateProject rewraps the project key for every active member and
+// delegated service role and re-encrypts every version in the current
+// format, keeping the key itself. Like a rotation it needs admin rights and
+// can be repeated after an interruption.
+func (app *App) MigrateProject(ctx context.Context, projectName string, progress func(RotateKeyProgress)) (*RotateKeyResult, error) {
+	rotation, err := config.LoadKeyRotation(projectName)
+	if err != nil {
+		return nil, err
+	}
+	if rotation != nil {
+		return nil, errors.New("a key rotation of this project is unfinished; run 'envcrypt project rotate-key' first")
+	}
+
+	access, err := app.unlockProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RotateKeyResult{}
+	if result.Members, result.ServiceRoles, err = app.rewrapProjectKey(ctx, access, access.PMK); err != nil {
+		return nil, err
+	}
+
+	if err := app.reencryptProject(ctx, access, access.PMK, access.PMK, result, progress); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -2,10 +2,8 @@ package app
 
 import (
 	"context"
-	"errors"
 
 	"github.com/envcrypts/envcrypt-cli/internal/config"
-	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
 	"github.com/google/uuid"
 )
 
@@ -35,15 +33,14 @@ func (app *App) PullEnvForCI(ctx context.Context, projectID uuid.UUID, envName s
 		return nil, err
 	}
 
-	envBytes, err := cryptoutils.DecryptENV(pmk, envResponse.CipherText, envResponse.Nonce)
+	access := &projectAccess{ProjectId: projectID, PMK: pmk}
+	env, _, err := app.openEnv(ctx, access, envName, config.EnvResponse(envResponse))
 	if err != nil {
-		return nil, errors.New("could not decrypt data")
+		return nil, err
 	}
-
-	envMap, err := cryptoutils.ReadCompressedEnv(envBytes)
-	if err != nil {
-		return nil, errors.New("could not parse environment variables")
+	if err := app.checkLegacyVersions(access, envName, *env); err != nil {
+		return nil, err
 	}
 
-	return envMap, nil
+	return env.Env, nil
 }
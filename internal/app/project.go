@@ -13,17 +13,23 @@ import (
 )
 
 func (app *App) CreateProject(ctx context.Context, projectName string) error {
-	email := viper.GetString("user.email")
+	email := viper.GetString(config.ContextKey("user.email"))
 	if email == "" {
 		return errors.New("no user email found")
 	}
 
-	userReq := config.UserKeyRequestBody{
-		Email: email,
+	uid, err := uuid.Parse(viper.GetString(config.ContextKey("user.id")))
+	if err != nil || uid == uuid.Nil {
+		return errors.New("user not authenticated")
 	}
 
-	var userResp config.UserKeyResponseBody
-	if err := app.HttpClient.Do(ctx, "POST", "/users/search", userReq, &userResp, false); err != nil {
+	// Wrap for the key derived locally rather than one the server hands out.
+	privateKey, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return err
+	}
+	publicKey, err := cryptoutils.PublicKeyFromPrivate(privateKey)
+	if err != nil {
 		return err
 	}
 
@@ -32,14 +38,14 @@ func (app *App) CreateProject(ctx context.Context, projectName string) error {
 		return err
 	}
 
-	wrappedKey, err := cryptoutils.WrapPMKForUser(pmk, userResp.PublicKey)
+	wrappedKey, err := cryptoutils.WrapPMKForUser(pmk, publicKey)
 	if err != nil {
 		return err
 	}
 
 	projectReq := config.ProjectCreateRequest{
 		Name:               projectName,
-		UserId:             userResp.UserId,
+		UserId:             uid,
 		WrappedPMK:         wrappedKey.WrappedPMK,
 		WrapNonce:          wrappedKey.WrapNonce,
 		EphemeralPublicKey: wrappedKey.WrapEphemeralPub,
@@ -54,7 +60,7 @@ func (app *App) CreateProject(ctx context.Context, projectName string) error {
 }
 
 func (app *App) ListProjects(ctx context.Context) (*config.ListProjectResponse, error) {
-	userId := viper.GetString("user.id")
+	userId := viper.GetString(config.ContextKey("user.id"))
 	if userId == "" {
 		return nil, errors.New("no user id found")
 	}
@@ -79,7 +85,7 @@ func (app *App) ListProjects(ctx context.Context) (*config.ListProjectResponse,
 }
 
 func (app *App) DeleteProject(ctx context.Context, projectName string) error {
-	email, userId := viper.GetString("user.email"), viper.GetString("user.id")
+	email, userId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
 
 	uid, err := uuid.Parse(userId)
 	if err != nil {
@@ -100,5 +106,5 @@ func (app *App) DeleteProject(ctx context.Context, projectName string) error {
 		return err
 	}
 
-	return nil
+	return config.DeleteProjectState(projectName)
 }
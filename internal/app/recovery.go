@@ -0,0 +1,125 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// ExportRecoveryKit encrypts the local private key with passphrase so it can
+// be stored offline and used to regain access after a lost password.
+func (app *App) ExportRecoveryKit(passphrase string) (*config.RecoveryKit, error) {
+	email, userId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
+	uid, err := uuid.Parse(userId)
+	if err != nil || email == "" {
+		return nil, errors.New("user not authenticated")
+	}
+
+	privateKeyBytes, err := cryptoutils.LoadPrivateKey(email)
+	if err != nil {
+		return nil, errors.New("no private key found in the keyring")
+	}
+
+	privateKey, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := cryptoutils.EncryptPrivateKey(privateKey, passphrase, &config.DefaultArgon2Params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.RecoveryKit{
+		Version:   config.RecoveryKitVersion,
+		Email:     email,
+		UserId:    uid,
+		Server:    config.BaseURL(),
+		CreatedAt: time.Now().UTC(),
+		PublicKey: privateKey.PublicKey().Bytes(),
+		Key:       *encryptedKey,
+	}, nil
+}
+
+// RestoreFromRecoveryKit decrypts the kit, proves possession of the private
+// key to the server and resets the account to newPassword. The private key
+// is then stored in the keyring as after a login.
+func (app *App) RestoreFromRecoveryKit(ctx context.Context, kit *config.RecoveryKit, passphrase, newPassword string) error {
+	if kit.Version != config.RecoveryKitVersion {
+		return fmt.Errorf("unsupported recovery kit version %d", kit.Version)
+	}
+
+	argonParams := kit.Key.ArgonParams.ParamsOrDefault()
+	if err := argonParams.Validate(); err != nil {
+		return fmt.Errorf("recovery kit is damaged: %w", err)
+	}
+	privateKeyBytes, err := cryptoutils.DecryptPrivateKey(&kit.Key, passphrase, &argonParams)
+	if err != nil {
+		return errors.New("wrong passphrase or damaged recovery kit")
+	}
+
+	privateKey, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(privateKey.PublicKey().Bytes(), kit.PublicKey) {
+		return errors.New("recovery kit is damaged: private and public key do not match")
+	}
+
+	challengeRequest := config.RecoveryChallengeRequest{Email: kit.Email}
+	var challengeResponse config.RecoveryChallengeResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/users/recovery/challenge", challengeRequest, &challengeResponse, false); err != nil {
+		return err
+	}
+
+	challenge, err := cryptoutils.UnwrapPMK(&cryptoutils.WrappedKey{
+		WrappedPMK:       challengeResponse.WrappedChallenge,
+		WrapNonce:        challengeResponse.WrapNonce,
+		WrapEphemeralPub: challengeResponse.EphemeralPublicKey,
+	}, privateKeyBytes)
+	if err != nil {
+		return errors.New("the recovery kit does not belong to this account")
+	}
+
+	encryptedKey, err := cryptoutils.EncryptPrivateKey(privateKey, newPassword, &config.DefaultArgon2Params)
+	if err != nil {
+		return err
+	}
+
+	resetRequest := config.RecoveryResetRequest{
+		Email:                   kit.Email,
+		Challenge:               challenge,
+		NewPassword:             newPassword,
+		EncryptedUserPrivateKey: encryptedKey.EncryptedUserPrivateKey,
+		PrivateKeySalt:          encryptedKey.PrivateKeySalt,
+		PrivateKeyNonce:         encryptedKey.PrivateKeyNonce,
+		ArgonParams:             encryptedKey.ArgonParams,
+	}
+	var resetResponse config.RecoveryResetResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/users/recovery/reset", resetRequest, &resetResponse, false); err != nil {
+		return err
+	}
+
+	if err := cryptoutils.SavePrivateKey(kit.Email, privateKeyBytes); err != nil {
+		return err
+	}
+	if err := config.SaveAccountKey(kit.Email, *encryptedKey); err != nil {
+		return err
+	}
+	if err := cryptoutils.SaveUserEmail(kit.Email); err != nil {
+		return err
+	}
+	if err := cryptoutils.SaveUserId(challengeResponse.UserId); err != nil {
+		return err
+	}
+
+	return pinOwnKey(kit.Email, privateKeyBytes)
+}
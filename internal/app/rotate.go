@@ -0,0 +1,321 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+)
+
+// RotateKeyProgress reports the re-encryption of a single version.
+type RotateKeyProgress struct {
+	EnvName string
+	Version int32
+	Done    int
+	Total   int
+	// Skipped is set when the version was already encrypted with the new key.
+	Skipped bool
+}
+
+type RotateKeyResult struct {
+	Resumed      bool
+	Members      int
+	ServiceRoles int
+	Reencrypted  int
+	Skipped      int
+}
+
+// RotateProjectKey replaces the project master key. The new key is wrapped
+// for every active member and delegated service role, then every version of
+// every environment is re-encrypted with it, newest first.
+//
+// Both keys are kept in a local journal sealed to the current user until the
+// rotation finishes, so an interrupted rotation is resumed by running it
+// again. Versions already encrypted with the new key are skipped.
+func (app *App) RotateProjectKey(ctx context.Context, projectName string, progress func(RotateKeyProgress)) (*RotateKeyResult, error) {
+	access, err := app.unlockProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := cryptoutils.LoadPrivateKey(access.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation, err := config.LoadKeyRotation(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RotateKeyResult{Resumed: rotation != nil}
+
+	var oldPMK, newPMK []byte
+	if rotation == nil {
+		rotation, oldPMK, newPMK, err = startKeyRotation(access, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.SaveKeyRotation(projectName, *rotation); err != nil {
+			return nil, err
+		}
+	} else {
+		if rotation.ProjectId != access.ProjectId {
+			return nil, errors.New("the saved rotation belongs to a different project with the same name")
+		}
+		if oldPMK, err = openSealedKey(rotation.OldKey, privateKey); err != nil {
+			return nil, err
+		}
+		if newPMK, err = openSealedKey(rotation.NewKey, privateKey); err != nil {
+			return nil, err
+		}
+
+		current := oldPMK
+		if rotation.Rewrapped {
+			current = newPMK
+		}
+		if !bytes.Equal(access.PMK, current) {
+			return nil, errors.New("the project key changed since this rotation started")
+		}
+	}
+
+	if !rotation.Rewrapped {
+		members, roles, err := app.rewrapProjectKey(ctx, access, newPMK)
+		if err != nil {
+			return nil, err
+		}
+		result.Members, result.ServiceRoles = members, roles
+
+		rotation.Rewrapped = true
+		if err := config.SaveKeyRotation(projectName, *rotation); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := app.reencryptProject(ctx, access, oldPMK, newPMK, result, progress); err != nil {
+		return nil, err
+	}
+
+	if err := config.DeleteKeyRotation(projectName); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func startKeyRotation(access *projectAccess, privateKey []byte) (*config.KeyRotation, []byte, []byte, error) {
+	publicKey, err := cryptoutils.PublicKeyFromPrivate(privateKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	newPMK := make([]byte, 32)
+	if _, err := rand.Read(newPMK); err != nil {
+		return nil, nil, nil, err
+	}
+
+	oldKey, err := sealKey(access.PMK, publicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	newKey, err := sealKey(newPMK, publicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &config.KeyRotation{
+		ProjectId: access.ProjectId,
+		OldKey:    *oldKey,
+		NewKey:    *newKey,
+		StartedAt: time.Now().UTC(),
+	}, access.PMK, newPMK, nil
+}
+
+func sealKey(pmk, publicKey []byte) (*config.SealedKey, error) {
+	wrapped, err := cryptoutils.WrapPMKForUser(pmk, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &config.SealedKey{
+		WrappedPMK:         wrapped.WrappedPMK,
+		WrapNonce:          wrapped.WrapNonce,
+		EphemeralPublicKey: wrapped.WrapEphemeralPub,
+	}, nil
+}
+
+func openSealedKey(key config.SealedKey, privateKey []byte) ([]byte, error) {
+	pmk, err := cryptoutils.UnwrapPMK(&cryptoutils.WrappedKey{
+		WrappedPMK:       key.WrappedPMK,
+		WrapNonce:        key.WrapNonce,
+		WrapEphemeralPub: key.EphemeralPublicKey,
+	}, privateKey)
+	if err != nil {
+		return nil, errors.New("could not unwrap the saved rotation key")
+	}
+	return pmk, nil
+}
+
+// rewrapProjectKey hands the new key to every active member and delegated
+// service role. Revoked members are left out.
+func (app *App) rewrapProjectKey(ctx context.Context, access *projectAccess, pmk []byte) (int, int, error) {
+	membersRequest := config.ProjectMembersRequest{
+		ProjectId: access.ProjectId,
+		AdminId:   access.UserId,
+	}
+	var membersResponse config.ProjectMembersResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/projects/members", membersRequest, &membersResponse, true); err != nil {
+		return 0, 0, err
+	}
+
+	rotateRequest := config.RotateProjectKeyRequest{
+		ProjectId: access.ProjectId,
+		AdminId:   access.UserId,
+	}
+
+	for _, member := range membersResponse.Members {
+		if member.IsRevoked {
+			continue
+		}
+		if err := app.checkMemberKey(ctx, member.Email, member.PublicKey); err != nil {
+			return 0, 0, err
+		}
+		wrapped, err := cryptoutils.WrapPMKForUser(pmk, member.PublicKey)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unable to wrap key for %s: %w", member.Email, err)
+		}
+		rotateRequest.Members = append(rotateRequest.Members, config.MemberWrappedKey{
+			UserId:             member.UserId,
+			WrappedPMK:         wrapped.WrappedPMK,
+			WrapNonce:          wrapped.WrapNonce,
+			EphemeralPublicKey: wrapped.WrapEphemeralPub,
+		})
+	}
+
+	for _, role := range membersResponse.ServiceRoles {
+		wrapped, err := cryptoutils.WrapPMKForUser(pmk, role.PublicKey)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unable to wrap key for service role %s: %w", role.RepoPrincipal, err)
+		}
+		rotateRequest.ServiceRoles = append(rotateRequest.ServiceRoles, config.ServiceRoleWrappedKey{
+			RepoPrincipal:      role.RepoPrincipal,
+			EnvName:            role.EnvName,
+			WrappedPMK:         wrapped.WrappedPMK,
+			WrapNonce:          wrapped.WrapNonce,
+			EphemeralPublicKey: wrapped.WrapEphemeralPub,
+		})
+	}
+
+	var rotateResponse config.RotateProjectKeyResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/projects/rotate-key", rotateRequest, &rotateResponse, true); err != nil {
+		return 0, 0, err
+	}
+
+	return len(rotateRequest.Members), len(rotateRequest.ServiceRoles), nil
+}
+
+type pendingVersion struct {
+	envName string
+	version config.EnvResponse
+}
+
+// reencryptProject moves every stored version from oldPMK to newPMK, in
+// the current ciphertext format. Versions that already are in that state are
+// skipped, which makes the pass safe to repeat after an interruption.
+func (app *App) reencryptProject(
+	ctx context.Context,
+	access *projectAccess,
+	oldPMK, newPMK []byte,
+	result *RotateKeyResult,
+	progress func(RotateKeyProgress),
+) error {
+	listRequest := config.ListEnvsRequest{
+		ProjectId: access.ProjectId,
+		Email:     access.Email,
+	}
+	var listResponse config.ListEnvsResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/env/list", listRequest, &listResponse, true); err != nil {
+		return err
+	}
+
+	var pending []pendingVersion
+	for _, envName := range listResponse.Envs {
+		versionsRequest := config.GetEnvVersionsRequest{
+			ProjectId: access.ProjectId,
+			Email:     access.Email,
+			EnvName:   envName,
+		}
+		var versionsResponse config.GetEnvVersionsResponse
+		if err := app.HttpClient.Do(ctx, "POST", "/env/search/all", versionsRequest, &versionsResponse, true); err != nil {
+			return err
+		}
+
+		// Heads first, so the versions people pull are readable soonest.
+		sort.Slice(versionsResponse.EnvVersions, func(i, j int) bool {
+			return versionsResponse.EnvVersions[i].Version > versionsResponse.EnvVersions[j].Version
+		})
+		for _, v := range versionsResponse.EnvVersions {
+			pending = append(pending, pendingVersion{envName: envName, version: v})
+		}
+	}
+
+	for i, p := range pending {
+		report := RotateKeyProgress{
+			EnvName: p.envName,
+			Version: p.version.Version,
+			Done:    i + 1,
+			Total:   len(pending),
+		}
+
+		ad := cryptoutils.EnvAssociatedData(access.ProjectId, p.envName, p.version.Version)
+
+		if _, format, err := cryptoutils.OpenEnv(newPMK, p.version.CipherText, p.version.Nonce, ad); err == nil && format == cryptoutils.EnvFormatEnvelope {
+			result.Skipped++
+			report.Skipped = true
+			if progress != nil {
+				progress(report)
+			}
+			continue
+		}
+
+		// A legacy version may already use the new key if it was pushed by
+		// someone who received the new key before it was re-encrypted.
+		data, _, err := cryptoutils.OpenEnv(oldPMK, p.version.CipherText, p.version.Nonce, ad)
+		if err != nil {
+			data, _, err = cryptoutils.OpenEnv(newPMK, p.version.CipherText, p.version.Nonce, ad)
+		}
+		if err != nil {
+			return fmt.Errorf("could not decrypt %s v%d with the old or new key", p.envName, p.version.Version)
+		}
+
+		cipherText, nonce, err := cryptoutils.SealEnv(newPMK, data, ad)
+		if err != nil {
+			return errors.New("could not encrypt data")
+		}
+
+		reencryptRequest := config.ReencryptEnvRequest{
+			ProjectId:  access.ProjectId,
+			UserId:     access.UserId,
+			EnvName:    p.envName,
+			Version:    p.version.Version,
+			CipherText: cipherText,
+			Nonce:      nonce,
+		}
+		var reencryptResponse config.ReencryptEnvResponse
+		if err := app.HttpClient.Do(ctx, "POST", "/env/reencrypt", reencryptRequest, &reencryptResponse, true); err != nil {
+			return fmt.Errorf("failed to store %s v%d: %w", p.envName, p.version.Version, err)
+		}
+
+		result.Reencrypted++
+		if progress != nil {
+			progress(report)
+		}
+	}
+
+	return nil
+}
@@ -13,7 +13,7 @@ import (
 
 func (app *App) ListServiceRoles(ctx context.Context) ([]config.ServiceRole, error) {
 
-	userID := viper.GetString("user.id")
+	userID := viper.GetString(config.ContextKey("user.id"))
 	if userID == "" {
 		return []config.ServiceRole{}, errors.New("user id not found")
 	}
@@ -38,7 +38,7 @@ func (app *App) ListServiceRoles(ctx context.Context) ([]config.ServiceRole, err
 
 func (app *App) CreateServiceRole(ctx context.Context, name, repoPrincipal string) (*config.ServiceRoleKeyPair, error) {
 
-	userID := viper.GetString("user.id")
+	userID := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(userID)
 
 	if err != nil {
@@ -82,7 +82,7 @@ func (app *App) GetServiceRole(ctx context.Context, repoPrincipal string) (*conf
 
 func (app *App) DeleteServiceRole(ctx context.Context, serviceRoleId uuid.UUID) error {
 
-	userID := viper.GetString("user.id")
+	userID := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		return errors.New("user id not valid")
@@ -115,7 +115,7 @@ func (app *App) GetPermissions(ctx context.Context, repoPrincipal string) (*conf
 }
 
 func (app *App) DelegateAccess(ctx context.Context, repoPrincipal, projectName, env string) error {
-	adminEmail, adminId := viper.GetString("user.email"), viper.GetString("user.id")
+	adminEmail, adminId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(adminId)
 	if err != nil || uid == uuid.Nil {
 		return errors.New("user not authenticated")
@@ -137,12 +137,7 @@ func (app *App) DelegateAccess(ctx context.Context, repoPrincipal, projectName,
 		WrapNonce:        projectResp.WrapNonce,
 		WrapEphemeralPub: projectResp.EphemeralPublicKey,
 	}
-	privateKey, err := cryptoutils.LoadPrivateKey(adminEmail)
-	if err != nil {
-		return errors.New("user not authenticated")
-	}
-
-	pmk, err := cryptoutils.UnwrapPMK(wrappedKey, privateKey)
+	pmk, err := app.unwrapPMK(adminEmail, wrappedKey)
 	if err != nil {
 		return errors.New("forbidden access: cannot unwrap project key")
 	}
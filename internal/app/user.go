@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"sort"
 
 	"github.com/envcrypts/envcrypt-cli/internal/config"
 	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
@@ -11,7 +12,7 @@ import (
 )
 
 func (app *App) AddUserToProject(ctx context.Context, memberEmail, projectName string) error {
-	adminEmail, adminId := viper.GetString("user.email"), viper.GetString("user.id")
+	adminEmail, adminId := viper.GetString(config.ContextKey("user.email")), viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(adminId)
 	if err != nil || uid == uuid.Nil {
 		return errors.New("user not authenticated")
@@ -32,23 +33,18 @@ func (app *App) AddUserToProject(ctx context.Context, memberEmail, projectName s
 		WrapNonce:        projectResp.WrapNonce,
 		WrapEphemeralPub: projectResp.EphemeralPublicKey,
 	}
-	privateKey, err := cryptoutils.LoadPrivateKey(adminEmail)
-	if err != nil {
-		return errors.New("user not authenticated")
-	}
-
-	pmk, err := cryptoutils.UnwrapPMK(wrappedKey, privateKey)
+	pmk, err := app.unwrapPMK(adminEmail, wrappedKey)
 	if err != nil {
 		return errors.New("forbidden access")
 	}
 
-	// Get Member's publicKey
-	pubKeyReq := config.UserKeyRequestBody{
-		Email: memberEmail,
-	}
-
-	var pubKeyResp config.UserKeyResponseBody
-	if err := app.HttpClient.Do(ctx, "POST", "/users/search", pubKeyReq, &pubKeyResp, false); err != nil {
+	// Get Member's publicKey, checked against the pinned one
+	pubKeyResp, err := app.lookupUser(ctx, memberEmail)
+	if err != nil {
+		var changed *KeyChangedError
+		if errors.As(err, &changed) {
+			return err
+		}
 		return errors.New("user not found")
 	}
 
@@ -77,7 +73,7 @@ func (app *App) AddUserToProject(ctx context.Context, memberEmail, projectName s
 
 func (app *App) RevokeAccess(ctx context.Context, projectName, userEmail string) error {
 
-	adminId := viper.GetString("user.id")
+	adminId := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(adminId)
 	if err != nil || uid == uuid.Nil {
 		return errors.New("user not authenticated")
@@ -99,7 +95,7 @@ func (app *App) RevokeAccess(ctx context.Context, projectName, userEmail string)
 }
 
 func (app *App) GiveAccess(ctx context.Context, projectName, userEmail string) error {
-	adminId := viper.GetString("user.id")
+	adminId := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(adminId)
 	if err != nil || uid == uuid.Nil {
 		return errors.New("user not authenticated")
@@ -118,3 +114,54 @@ func (app *App) GiveAccess(ctx context.Context, projectName, userEmail string) e
 
 	return nil
 }
+
+// ExposedEnv is an environment whose current values any past member of the
+// project could have read.
+type ExposedEnv struct {
+	EnvName string
+	Version int32
+	Keys    []string
+}
+
+// ExposedEnvs lists the head of every environment of a project with its keys,
+// i.e. the secrets a revoked member may have seen in plaintext.
+func (app *App) ExposedEnvs(ctx context.Context, projectName string) ([]ExposedEnv, error) {
+	access, err := app.unlockProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	listRequest := config.ListEnvsRequest{
+		ProjectId: access.ProjectId,
+		Email:     access.Email,
+	}
+	var listResponse config.ListEnvsResponse
+	if err := app.HttpClient.Do(ctx, "POST", "/env/list", listRequest, &listResponse, true); err != nil {
+		return nil, err
+	}
+
+	var exposed []ExposedEnv
+	for _, envName := range listResponse.Envs {
+		head, err := app.fetchEnv(ctx, access, envName, nil)
+		if err != nil {
+			return nil, err
+		}
+		if head == nil || len(head.Env) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(head.Env))
+		for k := range head.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		exposed = append(exposed, ExposedEnv{EnvName: envName, Version: head.Version, Keys: keys})
+	}
+
+	sort.Slice(exposed, func(i, j int) bool {
+		return exposed[i].EnvName < exposed[j].EnvName
+	})
+
+	return exposed, nil
+}
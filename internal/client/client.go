@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/envcrypts/envcrypt-cli/internal/config"
 	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
@@ -14,10 +15,16 @@ import (
 	"github.com/spf13/viper"
 )
 
+// refreshMargin is how long before expiry a session is refreshed.
+const refreshMargin = 30 * time.Second
+
 type Client struct {
-	baseUrl     string
-	http        *http.Client
-	accessToken uuid.UUID
+	baseUrl string
+	http    *http.Client
+
+	// session is loaded from the keystore on the first protected request.
+	session       *config.Session
+	sessionLoaded bool
 }
 
 func NewClient(baseUrl string, client *http.Client) *Client {
@@ -40,6 +47,12 @@ func (c *Client) Do(
 	protected bool,
 ) error {
 
+	if protected {
+		if err := c.ensureSession(ctx); err != nil {
+			return err
+		}
+	}
+
 	err := c.doOnce(ctx, method, path, body, out, protected)
 	if err == nil {
 		return nil
@@ -89,7 +102,11 @@ func (c *Client) doOnce(
 	req.Header.Set("Content-Type", "application/json")
 
 	if protected {
-		req.Header.Set("X-Session-ID", c.accessToken.String())
+		var accessToken uuid.UUID
+		if c.session != nil {
+			accessToken = c.session.AccessToken
+		}
+		req.Header.Set("X-Session-ID", accessToken.String())
 	}
 
 	resp, err := c.http.Do(req)
@@ -115,32 +132,117 @@ func (c *Client) doOnce(
 	return nil
 }
 
+// SetSession replaces the current session and persists it, e.g. after login.
+func (c *Client) SetSession(session config.Session) error {
+	c.session = &session
+	c.sessionLoaded = true
+
+	// Refresh tokens used to be kept in config.yaml in plaintext.
+	if viper.GetString(config.ContextKey("user.refresh_token")) != "" {
+		if err := cryptoutils.RemoveRefreshToken(); err != nil {
+			return err
+		}
+	}
+
+	return cryptoutils.SaveSession(viper.GetString(config.ContextKey("user.email")), session)
+}
+
+// ensureSession loads the stored session and refreshes it when it is about
+// to expire, so requests do not fail with 401 first.
+func (c *Client) ensureSession(ctx context.Context) error {
+	if !c.sessionLoaded {
+		c.sessionLoaded = true
+
+		email := viper.GetString(config.ContextKey("user.email"))
+		if email == "" {
+			return nil
+		}
+
+		session, err := cryptoutils.LoadSession(email)
+		if err != nil {
+			return err
+		}
+		c.session = session
+	}
+
+	if c.session == nil || time.Until(c.session.ExpiresAt) > refreshMargin {
+		return nil
+	}
+
+	// Other failures, such as network errors, are left to the request.
+	err := c.Refresh(ctx)
+	if errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrRefreshTokenReused) {
+		return err
+	}
+	return nil
+}
+
+// ErrSessionExpired means the refresh token was rejected and the user has
+// to log in again.
+var ErrSessionExpired = errors.New("session expired, please log in again")
+
+// ErrRefreshTokenReused means the server saw an already rotated refresh
+// token, which can indicate a stolen token. It revokes the session.
+var ErrRefreshTokenReused = errors.New("refresh token was already used; the session was revoked, please log in again")
+
+// Refresh exchanges the stored refresh token for a new session. The refresh
+// token rotates on every use, so refreshes are serialized across processes
+// and a process that waited for another one adopts its new session.
 func (c *Client) Refresh(ctx context.Context) error {
 
-	userID := viper.GetString("user.id")
+	email := viper.GetString(config.ContextKey("user.email"))
+	userID := viper.GetString(config.ContextKey("user.id"))
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		return err
 	}
 
-	req := config.RefreshRequestBody{
-		UserID: uid,
+	unlock, err := acquireRefreshLock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	var resp config.RefreshResponseBody
-
-	err = c.doOnce(ctx, "POST", "/users/refresh", req, &resp, false)
+	stored, err := cryptoutils.LoadSession(email)
 	if err != nil {
 		return err
 	}
+	if stored == nil {
+		return ErrSessionExpired
+	}
+
+	// Another process refreshed while we waited for the lock.
+	if c.session != nil && stored.AccessToken != c.session.AccessToken && time.Until(stored.ExpiresAt) > refreshMargin {
+		c.session = stored
+		return nil
+	}
+
+	req := config.RefreshRequestBody{
+		UserID:       uid,
+		RefreshToken: stored.RefreshToken,
+	}
+
+	var resp config.RefreshResponseBody
 
-	c.accessToken = resp.Session.AccessToken
-	err = cryptoutils.SaveRefreshToken(resp.Session.RefreshToken.String())
+	refreshCtx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	err = c.doOnce(refreshCtx, "POST", "/users/refresh", req, &resp, false)
 	if err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			switch httpErr.Status {
+			case http.StatusConflict:
+				_ = cryptoutils.DeleteSession(email)
+				return ErrRefreshTokenReused
+			case http.StatusUnauthorized, http.StatusForbidden:
+				_ = cryptoutils.DeleteSession(email)
+				return ErrSessionExpired
+			}
+		}
 		return err
 	}
 
-	return nil
+	return c.SetSession(resp.Session.Session())
 }
 
 type HTTPError struct {
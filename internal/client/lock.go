@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+)
+
+const (
+	// lockStale is the age after which a lock left by a crashed process is
+	// broken. A live holder gives up on its refresh well before that.
+	lockStale = 30 * time.Second
+	// lockTimeout outlasts lockStale, so a waiter breaks the lock of a
+	// crashed holder instead of giving up first.
+	lockTimeout = lockStale + 15*time.Second
+	// refreshTimeout bounds the refresh request made under the lock, so the
+	// lock is released before a waiter could consider it stale.
+	refreshTimeout = lockStale - 10*time.Second
+)
+
+// acquireRefreshLock serializes session refreshes across CLI processes, so
+// two of them never present the same refresh token. Each context has its
+// own lock. The returned function releases it.
+func acquireRefreshLock() (func(), error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, "refresh-"+config.CurrentContext()+".lock")
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		err := createLock(path, token)
+		if err == nil {
+			return func() { releaseLock(path, token) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if breakStaleLock(path, token) {
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for another envcrypt process to refresh the session (remove %s if none is running)", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// lockToken identifies this process as the holder of a lock.
+func lockToken() ([]byte, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%d-%s\n", os.Getpid(), hex.EncodeToString(nonce)), nil
+}
+
+// createLock writes token to a temporary file and links it into place, so
+// the lock never exists without its owner's token. The link fails with
+// os.ErrExist if the lock is held.
+func createLock(path string, token []byte) error {
+	tmp := path + "." + string(bytes.TrimSpace(token))
+	if err := os.WriteFile(tmp, token, 0600); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Link(tmp, path)
+}
+
+// breakStaleLock removes the lock at path if it is older than lockStale and
+// reports whether it did. The lock is first renamed aside, which only one
+// waiter can do; if what was moved is no longer the stale lock, it is put
+// back.
+func breakStaleLock(path string, token []byte) bool {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) <= lockStale {
+		return false
+	}
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	aside := path + "." + string(bytes.TrimSpace(token)) + ".stale"
+	if err := os.Rename(path, aside); err != nil {
+		return false
+	}
+	defer os.Remove(aside)
+
+	if moved, err := os.ReadFile(aside); err != nil || !bytes.Equal(moved, stale) {
+		// Another waiter broke the lock and took it in between.
+		os.Link(aside, path)
+	}
+	return true
+}
+
+// releaseLock removes the lock only while it still holds this process's
+// token, so a lock broken as stale and taken by another process stays.
+func releaseLock(path string, token []byte) {
+	held, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(held, token) {
+		return
+	}
+	os.Remove(path)
+}
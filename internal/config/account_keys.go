@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// The encrypted private key of each logged in account is kept locally as
+// the server last stored it, so the password can be checked without
+// logging in again.
+
+func accountKeysPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "account_keys.json"), nil
+}
+
+func readAccountKeys() (map[string]EncryptedPrivateKey, error) {
+	path, err := accountKeysPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]EncryptedPrivateKey{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]EncryptedPrivateKey{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func writeAccountKeys(keys map[string]EncryptedPrivateKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path, err := accountKeysPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadAccountKey returns the encrypted private key stored for email in the
+// active context, or nil if none is.
+func LoadAccountKey(email string) (*EncryptedPrivateKey, error) {
+	keys, err := readAccountKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok := keys[knownKeyName(email)]
+	if !ok {
+		return nil, nil
+	}
+	return &k, nil
+}
+
+func SaveAccountKey(email string, k EncryptedPrivateKey) error {
+	keys, err := readAccountKeys()
+	if err != nil {
+		return err
+	}
+	keys[knownKeyName(email)] = k
+	return writeAccountKeys(keys)
+}
+
+func DeleteAccountKey(email string) error {
+	keys, err := readAccountKeys()
+	if err != nil {
+		return err
+	}
+	if _, ok := keys[knownKeyName(email)]; !ok {
+		return nil
+	}
+	delete(keys, knownKeyName(email))
+	return writeAccountKeys(keys)
+}
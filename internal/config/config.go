@@ -3,17 +3,27 @@ package config
 import (
 	"os"
 	"path/filepath"
+
 	"github.com/spf13/viper"
 )
 
+// Dir returns the directory holding the CLI configuration and local state.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "envcrypt"), nil
+}
+
 func Load() error {
 
-	dir, err := os.UserConfigDir()
+	appDir, err := Dir()
 	if err != nil {
 		return err
 	}
 
-	appDir := filepath.Join(dir, "envcrypt")
 	if err := os.MkdirAll(appDir, 0755); err != nil {
 		return err
 	}
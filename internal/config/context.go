@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultContext is the context backed by the top-level api and user keys
+// of config.yaml, so configurations written before contexts existed keep
+// working unchanged.
+const DefaultContext = "default"
+
+var contextNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var contextOverride string
+
+// ValidateContextName rejects names that cannot be used as config keys.
+func ValidateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q (use lowercase letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// SetContextOverride selects a context for this process only, as done by
+// the --context flag or ENVCRYPT_CONTEXT.
+func SetContextOverride(name string) error {
+	if err := ValidateContextName(name); err != nil {
+		return err
+	}
+	if !ContextExists(name) {
+		return fmt.Errorf("context %q does not exist", name)
+	}
+	contextOverride = name
+	return nil
+}
+
+// CurrentContext returns the active context: the --context override, then
+// ENVCRYPT_CONTEXT, then current_context from config.yaml.
+func CurrentContext() string {
+	if contextOverride != "" {
+		return contextOverride
+	}
+	if name := viper.GetString("context"); name != "" {
+		return name
+	}
+	if name := viper.GetString("current_context"); name != "" {
+		return name
+	}
+	return DefaultContext
+}
+
+// ContextKey scopes a config key such as "user.email" to the active context.
+func ContextKey(key string) string {
+	return contextKey(CurrentContext(), key)
+}
+
+func contextKey(name, key string) string {
+	if name == DefaultContext {
+		return key
+	}
+	return "contexts." + name + "." + key
+}
+
+func ContextExists(name string) bool {
+	if name == DefaultContext {
+		return true
+	}
+	return viper.IsSet("contexts." + name)
+}
+
+// ListContexts returns all context names, including the default one.
+func ListContexts() []string {
+	names := []string{DefaultContext}
+	for name := range viper.GetStringMap("contexts") {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ContextBaseURL returns the server URL configured for a context.
+func ContextBaseURL(name string) string {
+	if url := viper.GetString(contextKey(name, "api.base_url")); url != "" {
+		return url
+	}
+	return viper.GetString("api.base_url")
+}
+
+// ContextUserEmail returns the identity logged in on a context.
+func ContextUserEmail(name string) string {
+	return viper.GetString(contextKey(name, "user.email"))
+}
+
+// BaseURL returns the server URL of the active context.
+func BaseURL() string {
+	return ContextBaseURL(CurrentContext())
+}
+
+// KeyringService returns the OS keyring service name for the active context,
+// keeping the keys of different servers and accounts apart.
+func KeyringService() string {
+	if name := CurrentContext(); name != DefaultContext {
+		return "envcrypt:" + name
+	}
+	return "envcrypt"
+}
+
+func AddContext(name, baseURL string) error {
+	if err := ValidateContextName(name); err != nil {
+		return err
+	}
+	if name == DefaultContext {
+		return errors.New("the default context always exists")
+	}
+	if ContextExists(name) {
+		return fmt.Errorf("context %q already exists", name)
+	}
+
+	viper.Set(contextKey(name, "api.base_url"), baseURL)
+	return WriteConfig()
+}
+
+func UseContext(name string) error {
+	if !ContextExists(name) {
+		return fmt.Errorf("context %q does not exist", name)
+	}
+
+	viper.Set("current_context", name)
+	return WriteConfig()
+}
+
+// WriteConfig persists viper's settings to config.yaml, creating it if needed.
+func WriteConfig() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return viper.WriteConfigAs(path)
+	}
+
+	return viper.WriteConfig()
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+)
+
 type Argon2idParams struct {
 	Time        uint32 `json:"time"`
 	Memory      uint32 `json:"memory"`
@@ -14,6 +19,44 @@ var DefaultArgon2Params = Argon2idParams{
 	KeyLength:   32,
 }
 
+// ParamsOrDefault returns p, or DefaultArgon2Params for accounts created
+// before the parameters were stored with the key.
+func (p Argon2idParams) ParamsOrDefault() Argon2idParams {
+	if p == (Argon2idParams{}) {
+		return DefaultArgon2Params
+	}
+	return p
+}
+
+// Bounds for Argon2id parameters received from elsewhere, memory in KiB.
+// Zero passes or lanes make argon2.IDKey panic, a huge memory cost would
+// exhaust memory and a huge number of passes would hang before any error.
+const (
+	minArgon2Memory = 8 * 1024
+	maxArgon2Memory = 1024 * 1024
+	maxArgon2Time   = 16
+)
+
+// Validate rejects parameters that cannot derive a key safely.
+func (p Argon2idParams) Validate() error {
+	switch {
+	case p.Time < 1 || p.Time > maxArgon2Time:
+		return fmt.Errorf("invalid Argon2id parameters: time must be between 1 and %d, not %d", maxArgon2Time, p.Time)
+	case p.Parallelism < 1:
+		return errors.New("invalid Argon2id parameters: parallelism must be at least 1")
+	case p.KeyLength != 32:
+		return fmt.Errorf("invalid Argon2id parameters: key length must be 32, not %d", p.KeyLength)
+	case p.Memory < minArgon2Memory || p.Memory > maxArgon2Memory:
+		return fmt.Errorf("invalid Argon2id parameters: memory must be between %d and %d KiB, not %d", minArgon2Memory, maxArgon2Memory, p.Memory)
+	}
+	return nil
+}
+
+// WeakerThan reports whether any cost parameter of p is below the one of o.
+func (p Argon2idParams) WeakerThan(o Argon2idParams) bool {
+	return p.Time < o.Time || p.Memory < o.Memory || p.Parallelism < o.Parallelism || p.KeyLength < o.KeyLength
+}
+
 type KeyPair struct {
 	PublicKey  []byte              `json:"public_key"`
 	PrivateKey []byte              `json:"private_key"`
@@ -25,7 +68,8 @@ type ServiceRoleKeyPair struct {
 	PrivateKey []byte `json:"private_key"`
 }
 type EncryptedPrivateKey struct {
-	EncryptedUserPrivateKey []byte `json:"encrypted_user_private_key"`
-	PrivateKeySalt          []byte `json:"private_key_salt"`
-	PrivateKeyNonce         []byte `json:"private_key_nonce"`
+	EncryptedUserPrivateKey []byte         `json:"encrypted_user_private_key"`
+	PrivateKeySalt          []byte         `json:"private_key_salt"`
+	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
+	ArgonParams             Argon2idParams `json:"argon_params"`
 }
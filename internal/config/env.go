@@ -1,9 +1,22 @@
 package config
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Metadata struct {
-	Type string `json:"type"`
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+
+	// Author and CreatedAt are filled in by the pushing client.
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
+	// PrevHash is the hash of the parent version, chaining the history of
+	// an environment. It is empty for the first version.
+	PrevHash []byte `json:"prev_hash,omitempty"`
 }
 type AddEnvRequest struct {
 	ProjectId uuid.UUID `json:"project_id"`
@@ -14,10 +27,18 @@ type AddEnvRequest struct {
 	Nonce      []byte `json:"nonce"`
 
 	Metadata Metadata `json:"metadata"`
+
+	// ParentVersion is the head the new version was based on. The server
+	// rejects the push with 409 Conflict when the head has moved since.
+	ParentVersion *int32 `json:"parent_version,omitempty"`
+
+	// Signature is the author's Ed25519 signature over the version.
+	Signature []byte `json:"signature,omitempty"`
 }
 
 type AddEnvResponse struct {
 	Message string `json:"message"`
+	Version int32  `json:"version"`
 }
 
 type GetEnvRequest struct {
@@ -29,8 +50,11 @@ type GetEnvRequest struct {
 }
 
 type GetEnvResponse struct {
-	CipherText []byte `json:"cipher_text"`
-	Nonce      []byte `json:"nonce"`
+	CipherText []byte   `json:"cipher_text"`
+	Nonce      []byte   `json:"nonce"`
+	Version    int32    `json:"version"`
+	Metadata   Metadata `json:"metadata"`
+	Signature  []byte   `json:"signature"`
 }
 
 type GetEnvVersionsRequest struct {
@@ -45,6 +69,7 @@ type EnvResponse struct {
 	Nonce      []byte   `json:"nonce"`
 	Version    int32    `json:"version"`
 	Metadata   Metadata `json:"metadata"`
+	Signature  []byte   `json:"signature"`
 }
 type GetEnvVersionsResponse struct {
 	EnvVersions []EnvResponse `json:"env_versions"`
@@ -59,3 +84,26 @@ type GetEnvForCIResponse struct {
 	Nonce      []byte `json:"nonce"`
 }
 
+// ListEnvsRequest POST /env/list
+type ListEnvsRequest struct {
+	ProjectId uuid.UUID `json:"project_id"`
+	Email     string    `json:"user_email"`
+}
+type ListEnvsResponse struct {
+	Envs []string `json:"envs"`
+}
+
+// ReencryptEnvRequest POST /env/reencrypt replaces the ciphertext of an
+// existing version, keeping its version number and metadata.
+type ReencryptEnvRequest struct {
+	ProjectId uuid.UUID `json:"project_id"`
+	UserId    uuid.UUID `json:"user_id"`
+
+	EnvName    string `json:"env_name"`
+	Version    int32  `json:"version"`
+	CipherText []byte `json:"cipher_text"`
+	Nonce      []byte `json:"nonce"`
+}
+type ReencryptEnvResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KnownKey is the public key pinned for a user the first time it was seen.
+// Later lookups must return the same key.
+type KnownKey struct {
+	PublicKey        []byte    `json:"public_key"`
+	SigningPublicKey []byte    `json:"signing_public_key,omitempty"`
+	FirstSeen        time.Time `json:"first_seen"`
+
+	// Verified is set once the key was compared out of band with
+	// 'envcrypt verify'.
+	Verified bool `json:"verified,omitempty"`
+}
+
+func knownKeysPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "known_keys.json"), nil
+}
+
+// knownKeyName scopes pins to the active context, since the same email can
+// belong to different accounts on different servers.
+func knownKeyName(email string) string {
+	if name := CurrentContext(); name != DefaultContext {
+		return name + ":" + email
+	}
+	return email
+}
+
+func readKnownKeys() (map[string]KnownKey, error) {
+	path, err := knownKeysPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]KnownKey{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]KnownKey{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// LoadKnownKey returns the key pinned for email, or nil if none is.
+func LoadKnownKey(email string) (*KnownKey, error) {
+	keys, err := readKnownKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok := keys[knownKeyName(email)]
+	if !ok {
+		return nil, nil
+	}
+	return &k, nil
+}
+
+func SaveKnownKey(email string, k KnownKey) error {
+	keys, err := readKnownKeys()
+	if err != nil {
+		return err
+	}
+	keys[knownKeyName(email)] = k
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path, err := knownKeysPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
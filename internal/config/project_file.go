@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ProjectFileName is the repository-local file binding a directory tree to
+// an EnvCrypt project.
+const ProjectFileName = ".envcrypt.yaml"
+
+// ProjectFile holds the defaults declared in .envcrypt.yaml.
+//
+//	project: my-app
+//	env: dev
+//	env_file: .env
+//	branches:
+//	  main: prod
+//	  release/*: staging
+type ProjectFile struct {
+	Project string `yaml:"project"`
+	Env     string `yaml:"env"`
+	EnvFile string `yaml:"env_file"`
+
+	// Branches maps git branch names, or path.Match patterns, to environments.
+	Branches map[string]string `yaml:"branches"`
+
+	// Path is where the file was found.
+	Path string `yaml:"-"`
+}
+
+// FindProjectFile looks for .envcrypt.yaml in dir and each of its parents.
+// It returns nil if no file is found.
+func FindProjectFile(dir string) (*ProjectFile, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectFileName)
+		data, err := os.ReadFile(candidate)
+		if err == nil {
+			var pf ProjectFile
+			if err := yaml.Unmarshal(data, &pf); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", candidate, err)
+			}
+			pf.Path = candidate
+			return &pf, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// EnvForBranch returns the environment mapped to branch. Exact names win
+// over patterns; patterns are tried in lexical order.
+func (pf *ProjectFile) EnvForBranch(branch string) string {
+	if env, ok := pf.Branches[branch]; ok {
+		return env
+	}
+
+	patterns := make([]string, 0, len(pf.Branches))
+	for p := range pf.Branches {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, branch); ok {
+			return pf.Branches[p]
+		}
+	}
+	return ""
+}
+
+// EnvFilePath returns env_file resolved relative to the project file.
+func (pf *ProjectFile) EnvFilePath() string {
+	if pf.EnvFile == "" || filepath.IsAbs(pf.EnvFile) {
+		return pf.EnvFile
+	}
+	return filepath.Join(filepath.Dir(pf.Path), pf.EnvFile)
+}
@@ -89,3 +89,55 @@ type GetProjectByRepo struct {
 type GetProjectByNameResponse struct {
 	ProjectID uuid.UUID `json:"project_id"`
 }
+
+// ProjectMembersRequest POST /projects/members
+type ProjectMembersRequest struct {
+	ProjectId uuid.UUID `json:"project_id"`
+	AdminId   uuid.UUID `json:"admin_id"`
+}
+
+type ProjectMember struct {
+	UserId    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	PublicKey []byte    `json:"public_key"`
+	IsRevoked bool      `json:"is_revoked"`
+}
+
+// ProjectServiceRole is a service role delegated to one environment.
+type ProjectServiceRole struct {
+	RepoPrincipal string `json:"repo_principal"`
+	EnvName       string `json:"env_name"`
+	PublicKey     []byte `json:"public_key"`
+}
+
+type ProjectMembersResponse struct {
+	Members      []ProjectMember      `json:"members"`
+	ServiceRoles []ProjectServiceRole `json:"service_roles"`
+}
+
+type MemberWrappedKey struct {
+	UserId             uuid.UUID `json:"user_id"`
+	WrappedPMK         []byte    `json:"wrapped_pmk"`
+	WrapNonce          []byte    `json:"wrap_nonce"`
+	EphemeralPublicKey []byte    `json:"ephemeral_public_key"`
+}
+
+type ServiceRoleWrappedKey struct {
+	RepoPrincipal      string `json:"repo_principal"`
+	EnvName            string `json:"env_name"`
+	WrappedPMK         []byte `json:"wrapped_pmk"`
+	WrapNonce          []byte `json:"wrap_nonce"`
+	EphemeralPublicKey []byte `json:"ephemeral_public_key"`
+}
+
+// RotateProjectKeyRequest POST /projects/rotate-key replaces the wrapped PMK
+// of every listed member and service role in one step.
+type RotateProjectKeyRequest struct {
+	ProjectId    uuid.UUID               `json:"project_id"`
+	AdminId      uuid.UUID               `json:"admin_id"`
+	Members      []MemberWrappedKey      `json:"members"`
+	ServiceRoles []ServiceRoleWrappedKey `json:"service_roles"`
+}
+type RotateProjectKeyResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const RecoveryKitVersion = 1
+
+// RecoveryKit is an offline backup of a private key, encrypted with a
+// passphrase that is separate from the account password.
+type RecoveryKit struct {
+	Version   int       `json:"version"`
+	Email     string    `json:"email"`
+	UserId    uuid.UUID `json:"user_id"`
+	Server    string    `json:"server"`
+	CreatedAt time.Time `json:"created_at"`
+
+	PublicKey []byte              `json:"public_key"`
+	Key       EncryptedPrivateKey `json:"encrypted_private_key"`
+}
+
+// RecoveryChallengeRequest POST /users/recovery/challenge
+type RecoveryChallengeRequest struct {
+	Email string `json:"email"`
+}
+
+// RecoveryChallengeResponse carries a random challenge wrapped to the
+// account's public key; only the holder of the private key can open it.
+type RecoveryChallengeResponse struct {
+	UserId             uuid.UUID `json:"user_id"`
+	WrappedChallenge   []byte    `json:"wrapped_challenge"`
+	WrapNonce          []byte    `json:"wrap_nonce"`
+	EphemeralPublicKey []byte    `json:"ephemeral_public_key"`
+}
+
+// RecoveryResetRequest POST /users/recovery/reset sets a new password and
+// encrypted private key after proving possession of the private key.
+type RecoveryResetRequest struct {
+	Email       string `json:"email"`
+	Challenge   []byte `json:"challenge"`
+	NewPassword string `json:"new_password"`
+
+	EncryptedUserPrivateKey []byte         `json:"encrypted_user_private_key"`
+	PrivateKeySalt          []byte         `json:"private_key_salt"`
+	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
+	ArgonParams             Argon2idParams `json:"argon_params"`
+}
+type RecoveryResetResponse struct {
+	Message string `json:"message"`
+}
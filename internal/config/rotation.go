@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SealedKey is a project master key wrapped for the local user, so it can be
+// kept on disk between runs.
+type SealedKey struct {
+	WrappedPMK         []byte `json:"wrapped_pmk"`
+	WrapNonce          []byte `json:"wrap_nonce"`
+	EphemeralPublicKey []byte `json:"ephemeral_public_key"`
+}
+
+// KeyRotation records an unfinished project key rotation so it can be
+// resumed. Both keys are needed until every version is re-encrypted.
+type KeyRotation struct {
+	ProjectId uuid.UUID `json:"project_id"`
+	OldKey    SealedKey `json:"old_key"`
+	NewKey    SealedKey `json:"new_key"`
+	StartedAt time.Time `json:"started_at"`
+
+	// Rewrapped is set once members and service roles received the new key.
+	Rewrapped bool `json:"rewrapped"`
+}
+
+func rotationsPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rotations.json"), nil
+}
+
+func readRotations() (map[string]KeyRotation, error) {
+	path, err := rotationsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]KeyRotation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := map[string]KeyRotation{}
+	if err := json.Unmarshal(data, &rotations); err != nil {
+		return nil, err
+	}
+	return rotations, nil
+}
+
+func writeRotations(rotations map[string]KeyRotation) error {
+	path, err := rotationsPath()
+	if err != nil {
+		return err
+	}
+
+	if len(rotations) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(rotations, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadKeyRotation returns the unfinished rotation of a project, or nil.
+func LoadKeyRotation(projectName string) (*KeyRotation, error) {
+	rotations, err := readRotations()
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := rotations[stateKey(projectName, "")]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func SaveKeyRotation(projectName string, r KeyRotation) error {
+	rotations, err := readRotations()
+	if err != nil {
+		return err
+	}
+	rotations[stateKey(projectName, "")] = r
+	return writeRotations(rotations)
+}
+
+func DeleteKeyRotation(projectName string) error {
+	rotations, err := readRotations()
+	if err != nil {
+		return err
+	}
+	delete(rotations, stateKey(projectName, ""))
+	return writeRotations(rotations)
+}
@@ -1,6 +1,26 @@
 package config
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login session as persisted in the keystore.
+type Session struct {
+	AccessToken  uuid.UUID `json:"access_token"`
+	RefreshToken uuid.UUID `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Session converts the server's session into one with an absolute expiry.
+func (b SessionBody) Session() Session {
+	return Session{
+		AccessToken:  b.AccessToken,
+		RefreshToken: b.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(b.ExpiresIn) * time.Second),
+	}
+}
 
 // ServiceRollProjectKeyRequest POST /service_role/project-key
 type ServiceRollProjectKeyRequest struct {
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvState is what the CLI remembers locally about a project environment.
+type EnvState struct {
+	// BaseVersion is the version the local env file was last pulled from or
+	// pushed as. Pushes use it to detect that someone else moved the head.
+	// It is 0 when no file has been pulled or pushed yet.
+	BaseVersion int32 `json:"base_version,omitempty"`
+
+	// HeadVersion and HeadHash describe the newest version seen on the
+	// server, so a server that rolls back or rewrites history is detected.
+	HeadVersion int32  `json:"head_version,omitempty"`
+	HeadHash    []byte `json:"head_hash,omitempty"`
+
+	// FirstSignedVersion is the oldest signed version seen. Unsigned
+	// versions are only accepted below it, as history from before signing.
+	FirstSignedVersion int32 `json:"first_signed_version,omitempty"`
+
+	// FirstBoundVersion is the oldest version seen whose ciphertext is bound
+	// to its environment and version. Unbound ciphertexts are only accepted
+	// below it, so one cannot be replayed as a newer version.
+	FirstBoundVersion int32 `json:"first_bound_version,omitempty"`
+}
+
+func statePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// stateKey scopes state to the active context, since the same project name
+// can exist on different servers.
+func stateKey(projectName, envName string) string {
+	key := projectName + "/" + envName
+	if name := CurrentContext(); name != DefaultContext {
+		key = name + ":" + key
+	}
+	return key
+}
+
+func readState() (map[string]EnvState, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]EnvState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]EnvState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// LoadEnvState returns the stored state for an environment, or nil if nothing
+// has been recorded yet.
+func LoadEnvState(projectName, envName string) (*EnvState, error) {
+	state, err := readState()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := state[stateKey(projectName, envName)]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func SaveEnvState(projectName, envName string, s EnvState) error {
+	return UpdateEnvState(projectName, envName, func(state *EnvState) {
+		*state = s
+	})
+}
+
+// UpdateEnvState applies update to the stored state of an environment,
+// starting from the zero state if there is none.
+func UpdateEnvState(projectName, envName string, update func(*EnvState)) error {
+	state, err := readState()
+	if err != nil {
+		return err
+	}
+
+	s := state[stateKey(projectName, envName)]
+	update(&s)
+	state[stateKey(projectName, envName)] = s
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeState(data)
+}
+
+// DeleteProjectState forgets the state of every environment of a project,
+// so a project created again under the same name starts afresh.
+func DeleteProjectState(projectName string) error {
+	state, err := readState()
+	if err != nil {
+		return err
+	}
+
+	prefix := stateKey(projectName, "")
+	for key := range state {
+		if strings.HasPrefix(key, prefix) {
+			delete(state, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeState(data)
+}
+
+// writeState replaces state.json through a temporary file, so a crash or a
+// concurrent write never leaves it truncated.
+func writeState(data []byte) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -10,6 +10,7 @@ type UserBody struct {
 	PrivateKeySalt          []byte         `json:"private_key_salt"`
 	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
 	ArgonParams             Argon2idParams `json:"argon_params"`
+	SigningPublicKey        []byte         `json:"signing_public_key"`
 }
 type SessionBody struct {
 	AccessToken  uuid.UUID `json:"access_token"`
@@ -20,10 +21,12 @@ type CreateRequestBody struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 
-	PublicKey               []byte `json:"public_key"`
-	EncryptedUserPrivateKey []byte `json:"encrypted_user_private_key"`
-	PrivateKeySalt          []byte `json:"private_key_salt"`
-	PrivateKeyNonce         []byte `json:"private_key_nonce"`
+	PublicKey               []byte         `json:"public_key"`
+	EncryptedUserPrivateKey []byte         `json:"encrypted_user_private_key"`
+	PrivateKeySalt          []byte         `json:"private_key_salt"`
+	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
+	ArgonParams             Argon2idParams `json:"argon_params"`
+	SigningPublicKey        []byte         `json:"signing_public_key"`
 }
 type CreateResponseBody struct {
 	Message string      `json:"message"`
@@ -45,13 +48,15 @@ type UserKeyRequestBody struct {
 	Email string `json:"email"`
 }
 type UserKeyResponseBody struct {
-	Message   string    `json:"message"`
-	UserId    uuid.UUID `json:"user_id"`
-	PublicKey []byte    `json:"public_key"`
+	Message          string    `json:"message"`
+	UserId           uuid.UUID `json:"user_id"`
+	PublicKey        []byte    `json:"public_key"`
+	SigningPublicKey []byte    `json:"signing_public_key"`
 }
 
 type RefreshRequestBody struct {
-	UserID uuid.UUID `json:"user_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	RefreshToken uuid.UUID `json:"refresh_token"`
 }
 type RefreshResponseBody struct {
 	Message string      `json:"message"`
@@ -64,3 +69,42 @@ type LogoutRequestBody struct {
 type LogoutResponseBody struct {
 	Message string `json:"message"`
 }
+
+// ChangePasswordRequestBody POST /users/password
+type ChangePasswordRequestBody struct {
+	UserID      uuid.UUID `json:"user_id"`
+	OldPassword string    `json:"old_password"`
+	NewPassword string    `json:"new_password"`
+
+	EncryptedUserPrivateKey []byte         `json:"encrypted_user_private_key"`
+	PrivateKeySalt          []byte         `json:"private_key_salt"`
+	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
+	ArgonParams             Argon2idParams `json:"argon_params"`
+}
+type ChangePasswordResponseBody struct {
+	Message string `json:"message"`
+}
+
+// UpdatePrivateKeyRequestBody POST /users/keys/update replaces the encrypted
+// private key, e.g. after upgrading its Argon2id parameters.
+type UpdatePrivateKeyRequestBody struct {
+	UserID uuid.UUID `json:"user_id"`
+
+	EncryptedUserPrivateKey []byte         `json:"encrypted_user_private_key"`
+	PrivateKeySalt          []byte         `json:"private_key_salt"`
+	PrivateKeyNonce         []byte         `json:"private_key_nonce"`
+	ArgonParams             Argon2idParams `json:"argon_params"`
+}
+type UpdatePrivateKeyResponseBody struct {
+	Message string `json:"message"`
+}
+
+// SigningKeyRequestBody POST /users/signing-key publishes the signing key of
+// accounts created before versions were signed.
+type SigningKeyRequestBody struct {
+	UserID           uuid.UUID `json:"user_id"`
+	SigningPublicKey []byte    `json:"signing_public_key"`
+}
+type SigningKeyResponseBody struct {
+	Message string `json:"message"`
+}
@@ -3,7 +3,12 @@ package cryptoutils
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"sort"
 	"strings"
@@ -19,15 +24,23 @@ func ParseEnv(raw []byte) (map[string]string, error) {
 
 	return env, nil
 }
-func EncodeEnv(env map[string]string) ([]byte, error) {
-	s, err := godotenv.Marshal(env)
-	if err != nil {
-		return nil, err
-	}
-	return []byte(s), nil
-}
 
-func NormalizeEnv(env map[string]string) []byte {
+var dotenvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	`"`, `\"`,
+	`!`, `\!`,
+	`$`, `\$`,
+	"`", "\\`",
+)
+
+// EncodeEnv renders env as a dotenv file. Unlike godotenv.Marshal values are
+// double quoted, so values such as "007" survive a round trip unchanged.
+// godotenv cannot read a double quoted value that ends in a backslash or a
+// quote, so those are written unquoted or single quoted when that reads back
+// the same, and rejected otherwise.
+func EncodeEnv(env map[string]string) ([]byte, error) {
 	keys := make([]string, 0, len(env))
 	for k := range env {
 		keys = append(keys, k)
@@ -36,13 +49,38 @@ func NormalizeEnv(env map[string]string) []byte {
 
 	var b strings.Builder
 	for _, k := range keys {
-		b.WriteString(k)
-		b.WriteString("=")
-		b.WriteString(env[k])
-		b.WriteString("\n")
+		line, err := encodeEnvLine(k, env[k])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(line)
 	}
+	return []byte(b.String()), nil
+}
 
-	return []byte(b.String())
+func encodeEnvLine(key, value string) (string, error) {
+	quoted := key + `="` + dotenvEscaper.Replace(value) + "\"\n"
+	if readsBack(quoted, key, value) {
+		return quoted, nil
+	}
+
+	unquoted := key + "=" + value + "\n"
+	if readsBack(unquoted, key, value) {
+		return unquoted, nil
+	}
+
+	single := key + "='" + value + "'\n"
+	if readsBack(single, key, value) {
+		return single, nil
+	}
+
+	return "", fmt.Errorf("the value of %s cannot be represented in dotenv format", key)
+}
+
+// readsBack reports whether godotenv parses line back to key=value.
+func readsBack(line, key, value string) bool {
+	env, err := godotenv.UnmarshalBytes([]byte(line))
+	return err == nil && len(env) == 1 && env[key] == value
 }
 
 func CompressEnv(data []byte) ([]byte, error) {
@@ -69,8 +107,13 @@ func DecompressEnv(data []byte) ([]byte, error) {
 	return io.ReadAll(gr)
 }
 
+// PrepareEnvForStorage encodes env with EncodeEnv, so every value reads
+// back exactly as given, and compresses it.
 func PrepareEnvForStorage(parsed map[string]string) ([]byte, error) {
-	normalized := NormalizeEnv(parsed)
+	normalized, err := EncodeEnv(parsed)
+	if err != nil {
+		return nil, err
+	}
 
 	compressed, err := CompressEnv(normalized)
 	if err != nil {
@@ -81,7 +124,10 @@ func PrepareEnvForStorage(parsed map[string]string) ([]byte, error) {
 }
 
 func PrepareEnvForRollback(env map[string]string) ([]byte, error) {
-	normalized := NormalizeEnv(env)
+	normalized, err := EncodeEnv(env)
+	if err != nil {
+		return nil, err
+	}
 
 	compressed, err := CompressEnv(normalized)
 	if err != nil {
@@ -125,5 +171,66 @@ func DiffEnvVersions(oldVersion, newVersion map[string]string) DiffingResult {
 		}
 	}
 
+	sort.Strings(Added)
+	sort.Strings(Removed)
+	sort.Strings(Modified)
+
 	return DiffingResult{Added: Added, Removed: Removed, Modified: Modified}
 }
+
+// MergeEnvVersions performs a three-way merge of local and remote against
+// their common base. Keys changed on only one side take that side's value;
+// keys changed on both sides to different results are reported as conflicts.
+func MergeEnvVersions(base, local, remote map[string]string) (map[string]string, []string) {
+	merged := make(map[string]string)
+	var conflicts []string
+
+	keys := make(map[string]struct{})
+	for _, m := range []map[string]string{base, local, remote} {
+		for k := range m {
+			keys[k] = struct{}{}
+		}
+	}
+
+	for key := range keys {
+		baseVal, inBase := base[key]
+		localVal, inLocal := local[key]
+		remoteVal, inRemote := remote[key]
+
+		localChanged := inLocal != inBase || localVal != baseVal
+		remoteChanged := inRemote != inBase || remoteVal != baseVal
+
+		switch {
+		case !localChanged:
+			if inRemote {
+				merged[key] = remoteVal
+			}
+		case !remoteChanged || (inLocal == inRemote && localVal == remoteVal):
+			if inLocal {
+				merged[key] = localVal
+			}
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// NewValueFingerprinter returns a function mapping values to short
+// fingerprints. It is keyed with a fresh random key, so fingerprints can be
+// compared within one output but cannot be matched across runs or used to
+// guess values offline.
+func NewValueFingerprinter() (func(string) string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil)[:4])
+	}, nil
+}
@@ -0,0 +1,206 @@
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Every blob encrypted by the CLI is an envelope:
+//
+//	magic "ECv" | version (1 byte) | suite (1 byte) | nonce | ciphertext
+//
+// The header is authenticated along with the caller's associated data, so
+// a blob cannot be relabelled with a weaker suite. Blobs written before
+// envelopes are raw AES-256-GCM ciphertexts with a separate nonce.
+var envelopeMagic = []byte("ECv")
+
+const (
+	envelopeVersion    = 1
+	envelopeHeaderSize = 5
+)
+
+// Suite identifies the AEAD used for an envelope.
+type Suite byte
+
+const (
+	SuiteAES256GCM         Suite = 1
+	SuiteXChaCha20Poly1305 Suite = 2
+)
+
+// ErrUnsupportedEnvelope is returned for envelopes written by a newer
+// version of the CLI.
+var ErrUnsupportedEnvelope = errors.New("unsupported encryption format; upgrade envcrypt")
+
+func (s Suite) String() string {
+	switch s {
+	case SuiteAES256GCM:
+		return "aes-256-gcm"
+	case SuiteXChaCha20Poly1305:
+		return "xchacha20-poly1305"
+	default:
+		return fmt.Sprintf("suite-%d", byte(s))
+	}
+}
+
+// ParseSuite returns the suite with the given name.
+func ParseSuite(name string) (Suite, error) {
+	for _, s := range []Suite{SuiteAES256GCM, SuiteXChaCha20Poly1305} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher suite %q (use %s or %s)", name, SuiteAES256GCM, SuiteXChaCha20Poly1305)
+}
+
+// DefaultSuite returns the suite new envelopes are written with: the
+// cipher_suite setting from config.yaml, defaulting to AES-256-GCM. An
+// unknown setting is an error rather than a silent fallback.
+func DefaultSuite() (Suite, error) {
+	name := viper.GetString("cipher_suite")
+	if name == "" {
+		return SuiteAES256GCM, nil
+	}
+	suite, err := ParseSuite(name)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cipher_suite in config.yaml: %w", err)
+	}
+	return suite, nil
+}
+
+func (s Suite) aead(key []byte) (cipher.AEAD, error) {
+	switch s {
+	case SuiteAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case SuiteXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, ErrUnsupportedEnvelope
+	}
+}
+
+// IsEnvelope reports whether blob starts with an envelope header. A legacy
+// ciphertext can do so by chance, so readers fall back to the legacy format
+// when opening the envelope fails.
+func IsEnvelope(blob []byte) bool {
+	return len(blob) >= envelopeHeaderSize && bytes.HasPrefix(blob, envelopeMagic)
+}
+
+// SealEnvelope encrypts plaintext with the default suite, authenticating ad.
+func SealEnvelope(key, plaintext, ad []byte) ([]byte, error) {
+	suite, err := DefaultSuite()
+	if err != nil {
+		return nil, err
+	}
+	return sealEnvelope(suite, key, plaintext, ad)
+}
+
+func sealEnvelope(suite Suite, key, plaintext, ad []byte) ([]byte, error) {
+	aead, err := suite.aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := append([]byte(nil), envelopeMagic...)
+	envelope = append(envelope, envelopeVersion, byte(suite))
+	header := envelope[:envelopeHeaderSize]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	envelope = append(envelope, nonce...)
+
+	return aead.Seal(envelope, nonce, plaintext, envelopeAD(header, ad)), nil
+}
+
+// OpenEnvelope decrypts an envelope written by SealEnvelope.
+func OpenEnvelope(key, envelope, ad []byte) ([]byte, error) {
+	if !IsEnvelope(envelope) {
+		return nil, errors.New("not an encrypted envelope")
+	}
+	header := envelope[:envelopeHeaderSize]
+	if header[len(envelopeMagic)] != envelopeVersion {
+		return nil, ErrUnsupportedEnvelope
+	}
+
+	aead, err := Suite(header[len(envelopeMagic)+1]).aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := envelope[envelopeHeaderSize:]
+	if len(body) < aead.NonceSize() {
+		return nil, errors.New("encrypted envelope is truncated")
+	}
+	nonce, cipherText := body[:aead.NonceSize()], body[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, cipherText, envelopeAD(header, ad))
+}
+
+// EnvelopeNonce returns the nonce stored in an envelope, for the nonce fields
+// the server still expects next to each ciphertext.
+func EnvelopeNonce(envelope []byte) []byte {
+	if !IsEnvelope(envelope) {
+		return nil
+	}
+	aead, err := Suite(envelope[len(envelopeMagic)+1]).aead(make([]byte, 32))
+	if err != nil || len(envelope) < envelopeHeaderSize+aead.NonceSize() {
+		return nil
+	}
+	return append([]byte(nil), envelope[envelopeHeaderSize:envelopeHeaderSize+aead.NonceSize()]...)
+}
+
+func envelopeAD(header, ad []byte) []byte {
+	return append(append([]byte(nil), header...), ad...)
+}
+
+// openBlob opens an envelope, or a legacy AES-256-GCM ciphertext without
+// associated data. legacy reports the latter, which should be re-encrypted.
+func openBlob(key, blob, nonce, ad []byte) (data []byte, legacy bool, err error) {
+	if IsEnvelope(blob) {
+		data, err := OpenEnvelope(key, blob, ad)
+		if err == nil {
+			return data, false, nil
+		}
+		if errors.Is(err, ErrUnsupportedEnvelope) {
+			// Likely from a newer CLI, but a legacy ciphertext could carry
+			// the same bytes; only report it if that fails too.
+			if data, legacyErr := openLegacy(key, blob, nonce, nil); legacyErr == nil {
+				return data, true, nil
+			}
+			return nil, false, err
+		}
+	}
+
+	data, err = openLegacy(key, blob, nonce, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func openLegacy(key, cipherText, nonce, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce length")
+	}
+	return gcm.Open(nil, nonce, cipherText, ad)
+}
@@ -0,0 +1,68 @@
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint is a short, readable digest of a public key, such as
+// "3f2a 91c0 77de 0b41 c2e8 5a19 d4f6 0e83".
+func Fingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	digits := hex.EncodeToString(sum[:16])
+
+	groups := make([]string, 0, len(digits)/4)
+	for i := 0; i < len(digits); i += 4 {
+		groups = append(groups, digits[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
+
+// SafetyNumber combines the identities of two users, each an email with its
+// encryption and signing keys, into 60 digits that both of them compute
+// identically, so they can compare keys out of band.
+func SafetyNumber(emailA string, keyA, signingKeyA []byte, emailB string, keyB, signingKeyB []byte) string {
+	a := safetyNumberHalf(emailA, keyA, signingKeyA)
+	b := safetyNumberHalf(emailB, keyB, signingKeyB)
+	if a > b {
+		a, b = b, a
+	}
+	digits := a + b
+
+	groups := make([]string, 0, len(digits)/5)
+	for i := 0; i < len(digits); i += 5 {
+		groups = append(groups, digits[i:i+5])
+	}
+	return strings.Join(groups, " ")
+}
+
+// safetyNumberHalf derives 30 digits from one identity. The hash is iterated
+// to make searching for a key with the same digits expensive.
+func safetyNumberHalf(email string, publicKey, signingKey []byte) string {
+	var identity bytes.Buffer
+	identity.WriteString("envcrypt-safety-number-v1")
+	for _, field := range [][]byte{[]byte(email), publicKey, signingKey} {
+		binary.Write(&identity, binary.BigEndian, uint32(len(field)))
+		identity.Write(field)
+	}
+
+	sum := sha512.Sum512(identity.Bytes())
+	for i := 0; i < 5200; i++ {
+		h := sha512.New()
+		h.Write(sum[:])
+		h.Write(publicKey)
+		h.Sum(sum[:0])
+	}
+
+	var digits strings.Builder
+	for i := 0; i < 30; i += 5 {
+		chunk := uint64(sum[i])<<32 | uint64(sum[i+1])<<24 | uint64(sum[i+2])<<16 | uint64(sum[i+3])<<8 | uint64(sum[i+4])
+		fmt.Fprintf(&digits, "%05d", chunk%100000)
+	}
+	return digits.String()
+}
@@ -1,100 +1,115 @@
 package cryptoutils
 
 import (
-	"encoding/base64"
-	"os"
-	"path/filepath"
+	"encoding/json"
+	"errors"
 
+	"github.com/envcrypts/envcrypt-cli/internal/config"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
-	"github.com/zalando/go-keyring"
 )
 
 func SavePrivateKey(user string, secret []byte) error {
-	encoded := base64.StdEncoding.EncodeToString(secret)
-	err := keyring.Set("envcrypt", user, encoded)
+	store, err := OpenKeystore()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return store.Set(config.KeyringService(), user, secret)
 }
 
 func LoadPrivateKey(user string) ([]byte, error) {
-	secret, err := keyring.Get("envcrypt", user)
+	store, err := OpenKeystore()
 	if err != nil {
 		return nil, err
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(secret)
+	return store.Get(config.KeyringService(), user)
+}
+
+func DeletePrivateKey(user string) error {
+	store, err := OpenKeystore()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return decoded, nil
+	_ = store.Delete(config.KeyringService(), user)
+	return nil
 }
 
-func DeletePrivateKey(user string) error {
-	_ = keyring.Delete("envcrypt", user)
-	return nil
+func sessionAccount(user string) string {
+	return "session:" + user
 }
 
-func SaveUserEmail(email string) error {
-	viper.Set("user.email", email)
+// SaveSession stores the login session of user in the keystore.
+func SaveSession(user string, session config.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
 
-	dir, err := os.UserConfigDir()
+	store, err := OpenKeystore()
 	if err != nil {
 		return err
 	}
 
-	appDir := filepath.Join(dir, "envcrypt")
-	path := filepath.Join(appDir, "config.yaml")
+	return store.Set(config.KeyringService(), sessionAccount(user), data)
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return viper.WriteConfigAs(path)
+// LoadSession returns the stored session of user, or nil if there is none.
+func LoadSession(user string) (*config.Session, error) {
+	store, err := OpenKeystore()
+	if err != nil {
+		return nil, err
 	}
 
-	return viper.WriteConfig()
-}
+	data, err := store.Get(config.KeyringService(), sessionAccount(user))
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-func RemoveUserEmail() error {
-	viper.Set("user.email", "")
-	return viper.WriteConfig()
+	var session config.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
 }
 
-func SaveUserId(id uuid.UUID) error {
-	viper.Set("user.id", id.String())
-
-	dir, err := os.UserConfigDir()
+func DeleteSession(user string) error {
+	store, err := OpenKeystore()
 	if err != nil {
 		return err
 	}
 
-	appDir := filepath.Join(dir, "envcrypt")
-	path := filepath.Join(appDir, "config.yaml")
+	return store.Delete(config.KeyringService(), sessionAccount(user))
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return viper.WriteConfigAs(path)
-	}
+func SaveUserEmail(email string) error {
+	viper.Set(config.ContextKey("user.email"), email)
+	return config.WriteConfig()
+}
 
-	return viper.WriteConfig()
+func RemoveUserEmail() error {
+	viper.Set(config.ContextKey("user.email"), "")
+	return config.WriteConfig()
 }
 
-func SaveRefreshToken(refreshToken string) error {
-	viper.Set("user.refresh_token", refreshToken)
-	dir, err := os.UserConfigDir()
-	if err != nil {
-		return err
-	}
-	appDir := filepath.Join(dir, "envcrypt")
-	path := filepath.Join(appDir, "config.yaml")
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return viper.WriteConfigAs(path)
-	}
-	return viper.WriteConfig()
+func SaveUserId(id uuid.UUID) error {
+	viper.Set(config.ContextKey("user.id"), id.String())
+	return config.WriteConfig()
+}
+
+// RemoveRefreshToken clears a refresh token stored in config.yaml by older
+// versions; sessions now live in the keystore.
+func RemoveRefreshToken() error {
+	viper.Set(config.ContextKey("user.refresh_token"), "")
+	return config.WriteConfig()
 }
 
 func RemoveUserId() error {
-	viper.Set("user.id", "")
-	return viper.WriteConfig()
+	viper.Set(config.ContextKey("user.id"), "")
+	return config.WriteConfig()
 }
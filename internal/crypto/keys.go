@@ -1,8 +1,6 @@
 package cryptoutils
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/ecdh"
 	"crypto/rand"
 	"errors"
@@ -11,7 +9,14 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
+// privateKeyAD labels encrypted private keys, so no other blob encrypted
+// under a password-derived key can stand in for one.
+var privateKeyAD = []byte("envcrypt-private-key")
+
 func EncryptPrivateKey(privateKey *ecdh.PrivateKey, password string, argonParams *config.Argon2idParams) (*config.EncryptedPrivateKey, error) {
+	if err := argonParams.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Generating Salt for Argon using crypto/rand
 	salt := make([]byte, 16)
@@ -30,31 +35,17 @@ func EncryptPrivateKey(privateKey *ecdh.PrivateKey, password string, argonParams
 		argonParams.KeyLength,
 	)
 
-	// Create AES block cipher
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create GCM instance
-	gcm, err := cipher.NewGCM(block)
+	// Encrypt the private key into an envelope
+	encryptedPrivateKey, err := SealEnvelope(encryptionKey, privateKey.Bytes(), privateKeyAD)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create AES nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = rand.Read(nonce); err != nil {
-		return nil, err
-	}
-
-	// AES_GCM encryption for the private key
-	encryptedPrivateKey := gcm.Seal(nil, nonce, privateKey.Bytes(), nil)
-
 	return &config.EncryptedPrivateKey{
 		EncryptedUserPrivateKey: encryptedPrivateKey,
 		PrivateKeySalt:          salt,
-		PrivateKeyNonce:         nonce,
+		PrivateKeyNonce:         EnvelopeNonce(encryptedPrivateKey),
+		ArgonParams:             *argonParams,
 	}, nil
 }
 
@@ -63,6 +54,9 @@ func DecryptPrivateKey(
 	password string,
 	argonParams *config.Argon2idParams,
 ) ([]byte, error) {
+	if err := argonParams.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Derive the same encryption key using Argon2id
 	encryptionKey := argon2.IDKey(
@@ -74,24 +68,12 @@ func DecryptPrivateKey(
 		argonParams.KeyLength,
 	)
 
-	// Create AES block cipher
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create GCM instance
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	// 4. Decrypt (Open = authenticated decrypt)
-	plaintextPrivateKey, err := gcm.Open(
-		nil,
-		encryptedPrivateKey.PrivateKeyNonce,
+	// Decrypt (authenticated); keys from before envelopes are raw AES-GCM
+	plaintextPrivateKey, _, err := openBlob(
+		encryptionKey,
 		encryptedPrivateKey.EncryptedUserPrivateKey,
-		nil,
+		encryptedPrivateKey.PrivateKeyNonce,
+		privateKeyAD,
 	)
 	if err != nil {
 		// This error covers:
@@ -0,0 +1,139 @@
+package cryptoutils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/spf13/viper"
+	"github.com/zalando/go-keyring"
+)
+
+// ErrSecretNotFound is returned by a Keystore when no secret is stored for
+// the requested account.
+var ErrSecretNotFound = errors.New("secret not found in keystore")
+
+// Keystore stores secrets such as private keys on the local machine.
+// Entries are grouped by service, which separates contexts.
+type Keystore interface {
+	Set(service, account string, secret []byte) error
+	Get(service, account string) ([]byte, error)
+	Delete(service, account string) error
+}
+
+const (
+	KeystoreAuto    = "auto"
+	KeystoreKeyring = "keyring"
+	KeystoreFile    = "file"
+)
+
+// KeystoreBackend returns the configured backend: the keystore setting from
+// --keystore, ENVCRYPT_KEYSTORE or config.yaml, defaulting to auto.
+func KeystoreBackend() string {
+	if name := viper.GetString("keystore"); name != "" {
+		return name
+	}
+	return KeystoreAuto
+}
+
+// OpenKeystore returns the configured Keystore. The auto backend uses the OS
+// keyring and falls back to the encrypted file when no keyring is available.
+func OpenKeystore() (Keystore, error) {
+	switch backend := KeystoreBackend(); backend {
+	case KeystoreKeyring:
+		return keyringStore{}, nil
+	case KeystoreFile:
+		return fileKeystore(), nil
+	case KeystoreAuto:
+		return autoStore{keyring: keyringStore{}, file: fileKeystore()}, nil
+	default:
+		return nil, fmt.Errorf("unknown keystore %q (use %s, %s or %s)", backend, KeystoreAuto, KeystoreKeyring, KeystoreFile)
+	}
+}
+
+// UpgradeKeystore re-encrypts file keystore entries written before
+// envelopes. The OS keyring stores secrets as they are and needs no upgrade.
+func UpgradeKeystore() (int, error) {
+	if KeystoreBackend() == KeystoreKeyring {
+		return 0, nil
+	}
+	return fileKeystore().Upgrade()
+}
+
+var (
+	fileKeystoreOnce   sync.Once
+	sharedFileKeystore *FileKeystore
+)
+
+// fileKeystore returns the file keystore of this process. It is shared so
+// that once unlocked, it stays unlocked.
+func fileKeystore() *FileKeystore {
+	fileKeystoreOnce.Do(func() {
+		dir, err := config.Dir()
+		if err != nil {
+			dir = "."
+		}
+		sharedFileKeystore = NewFileKeystore(dir)
+	})
+	return sharedFileKeystore
+}
+
+// keyringStore keeps secrets in the OS keyring via go-keyring.
+type keyringStore struct{}
+
+func (keyringStore) Set(service, account string, secret []byte) error {
+	return keyring.Set(service, account, base64Encode(secret))
+}
+
+func (keyringStore) Get(service, account string) ([]byte, error) {
+	secret, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64Decode(secret)
+}
+
+func (keyringStore) Delete(service, account string) error {
+	err := keyring.Delete(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// autoStore prefers the OS keyring. When the keyring is unusable, for
+// example on headless Linux without a Secret Service, it uses the file.
+type autoStore struct {
+	keyring Keystore
+	file    *FileKeystore
+}
+
+func (s autoStore) Set(service, account string, secret []byte) error {
+	if err := s.keyring.Set(service, account, secret); err != nil {
+		return s.file.Set(service, account, secret)
+	}
+	return nil
+}
+
+func (s autoStore) Get(service, account string) ([]byte, error) {
+	secret, err := s.keyring.Get(service, account)
+	if err == nil {
+		return secret, nil
+	}
+	if !s.file.Exists() {
+		return nil, err
+	}
+	return s.file.Get(service, account)
+}
+
+func (s autoStore) Delete(service, account string) error {
+	err := s.keyring.Delete(service, account)
+	if s.file.Exists() {
+		return s.file.Delete(service, account)
+	}
+	return err
+}
@@ -0,0 +1,320 @@
+package cryptoutils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"golang.org/x/crypto/argon2"
+)
+
+// PassphrasePrompt asks the user for the file keystore passphrase when
+// ENVCRYPT_KEYSTORE_PASSPHRASE is not set. create is true when the keystore
+// does not exist yet, so the passphrase should be confirmed. It is set by the
+// CLI; without it the environment variable is required.
+var PassphrasePrompt func(create bool) (string, error)
+
+const keystoreCheck = "envcrypt-keystore"
+
+type keystoreEntry struct {
+	CipherText []byte `json:"cipher_text"`
+	// Nonce is only set for entries written before envelopes.
+	Nonce []byte `json:"nonce,omitempty"`
+}
+
+type keystoreFileData struct {
+	Version     int                      `json:"version"`
+	Salt        []byte                   `json:"salt"`
+	ArgonParams config.Argon2idParams    `json:"argon_params"`
+	Check       keystoreEntry            `json:"check"`
+	Entries     map[string]keystoreEntry `json:"entries"`
+}
+
+// FileKeystore keeps secrets in a single file, each entry encrypted into an
+// envelope under a key derived from a passphrase with Argon2id.
+type FileKeystore struct {
+	path string
+
+	// mu guards key, which is kept once unlocked so the passphrase is only
+	// asked for once per process.
+	mu  sync.Mutex
+	key []byte
+}
+
+func NewFileKeystore(dir string) *FileKeystore {
+	return &FileKeystore{path: filepath.Join(dir, "keystore.json")}
+}
+
+func (s *FileKeystore) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// entryAD binds an entry to its service and account, so entries cannot be
+// swapped within the file.
+func entryAD(service, account string) []byte {
+	return []byte(service + "\x00" + account)
+}
+
+func (s *FileKeystore) Set(service, account string, secret []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.open(true)
+	if err != nil {
+		return err
+	}
+
+	cipherText, err := SealEnvelope(s.key, secret, entryAD(service, account))
+	if err != nil {
+		return err
+	}
+	data.Entries[service+"/"+account] = keystoreEntry{CipherText: cipherText}
+
+	return s.write(data)
+}
+
+func (s *FileKeystore) Get(service, account string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.Exists() {
+		return nil, ErrSecretNotFound
+	}
+
+	data, err := s.open(false)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := data.Entries[service+"/"+account]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+
+	secret, _, err := openBlob(s.key, entry.CipherText, entry.Nonce, entryAD(service, account))
+	if err != nil {
+		return nil, errors.New("keystore entry is damaged")
+	}
+	return secret, nil
+}
+
+func (s *FileKeystore) Delete(service, account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read()
+	if err != nil || data == nil {
+		return err
+	}
+
+	delete(data.Entries, service+"/"+account)
+	return s.write(data)
+}
+
+// Upgrade re-encrypts entries written before envelopes and returns how many
+// it upgraded.
+func (s *FileKeystore) Upgrade() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.Exists() {
+		return 0, nil
+	}
+
+	data, err := s.open(false)
+	if err != nil {
+		return 0, err
+	}
+
+	upgrade := func(entry *keystoreEntry, ad []byte) (bool, error) {
+		secret, legacy, err := openBlob(s.key, entry.CipherText, entry.Nonce, ad)
+		if err != nil {
+			return false, errors.New("keystore entry is damaged")
+		}
+		if !legacy {
+			return false, nil
+		}
+		cipherText, err := SealEnvelope(s.key, secret, ad)
+		if err != nil {
+			return false, err
+		}
+		*entry = keystoreEntry{CipherText: cipherText}
+		return true, nil
+	}
+
+	upgraded := 0
+	ok, err := upgrade(&data.Check, nil)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		upgraded++
+	}
+	for name, entry := range data.Entries {
+		service, account, _ := strings.Cut(name, "/")
+		ok, err := upgrade(&entry, entryAD(service, account))
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			data.Entries[name] = entry
+			upgraded++
+		}
+	}
+
+	if upgraded == 0 {
+		return 0, nil
+	}
+	return upgraded, s.write(data)
+}
+
+func (s *FileKeystore) read() (*keystoreFileData, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data keystoreFileData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	if data.Entries == nil {
+		data.Entries = map[string]keystoreEntry{}
+	}
+	return &data, nil
+}
+
+// open reads the keystore and unlocks it, creating it if allowed.
+func (s *FileKeystore) open(create bool) (*keystoreFileData, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		if !create {
+			return nil, ErrSecretNotFound
+		}
+		return s.create()
+	}
+
+	if s.key != nil {
+		return data, nil
+	}
+
+	passphrase, err := keystorePassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := data.ArgonParams.Validate(); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(passphrase), data.Salt, data.ArgonParams.Time, data.ArgonParams.Memory, data.ArgonParams.Parallelism, data.ArgonParams.KeyLength)
+	if _, err := DecryptENV(key, data.Check.CipherText, data.Check.Nonce); err != nil {
+		return nil, errors.New("wrong keystore passphrase")
+	}
+
+	s.key = key
+	return data, nil
+}
+
+func (s *FileKeystore) create() (*keystoreFileData, error) {
+	passphrase, err := keystorePassphrase(true)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	params := config.DefaultArgon2Params
+	key := argon2.IDKey([]byte(passphrase), salt, params.Time, params.Memory, params.Parallelism, params.KeyLength)
+
+	cipherText, err := SealEnvelope(key, []byte(keystoreCheck), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.key = key
+	return &keystoreFileData{
+		Version:     1,
+		Salt:        salt,
+		ArgonParams: params,
+		Check:       keystoreEntry{CipherText: cipherText},
+		Entries:     map[string]keystoreEntry{},
+	}, nil
+}
+
+func (s *FileKeystore) write(data *keystoreFileData) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so an interrupted write cannot lose
+	// the keys already stored.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".keystore-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func keystorePassphrase(create bool) (string, error) {
+	if passphrase := os.Getenv("ENVCRYPT_KEYSTORE_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if PassphrasePrompt == nil {
+		return "", errors.New("the keystore is locked; set ENVCRYPT_KEYSTORE_PASSPHRASE")
+	}
+
+	passphrase, err := PassphrasePrompt(create)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("keystore passphrase is required")
+	}
+	return passphrase, nil
+}
+
+func base64Encode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func base64Decode(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s)
+}
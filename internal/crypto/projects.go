@@ -1,14 +1,14 @@
 package cryptoutils
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/ecdh"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"io"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -115,27 +115,15 @@ func WrapPMKForUser(
 		return nil, err
 	}
 
-	// 4. Encrypt PMK using AES-256-GCM
-	block, err := aes.NewCipher(wrapKey)
+	// 4. Encrypt PMK into an envelope bound to both public keys
+	wrappedPMK, err := SealEnvelope(wrapKey, pmk, wrapAssociatedData(ephemeral.PublicKey, recipientUserPublicKey))
 	if err != nil {
 		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return nil, err
-	}
-
-	wrappedPMK := gcm.Seal(nil, nonce, pmk, nil)
-
 	return &WrappedKey{
 		WrappedPMK:       wrappedPMK,
-		WrapNonce:        nonce,
+		WrapNonce:        EnvelopeNonce(wrappedPMK),
 		WrapEphemeralPub: ephemeral.PublicKey,
 	}, nil
 }
@@ -176,22 +164,17 @@ func UnwrapPMK(
 		return nil, err
 	}
 
-	// 3. Decrypt PMK
-	block, err := aes.NewCipher(wrapKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	// 3. Decrypt PMK; keys wrapped before envelopes are raw AES-GCM
+	userPublicKey, err := PublicKeyFromPrivate(userPrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	pmk, err := gcm.Open(
-		nil,
-		wrapped.WrapNonce,
+	pmk, _, err := openBlob(
+		wrapKey,
 		wrapped.WrappedPMK,
-		nil,
+		wrapped.WrapNonce,
+		wrapAssociatedData(wrapped.WrapEphemeralPub, userPublicKey),
 	)
 	if err != nil {
 		return nil, err
@@ -200,38 +183,96 @@ func UnwrapPMK(
 	return pmk, nil
 }
 
+// wrapAssociatedData binds a wrapped key to the ephemeral key it was wrapped
+// with and to its recipient.
+func wrapAssociatedData(ephemeralPublicKey, recipientPublicKey []byte) []byte {
+	ad := []byte("envcrypt-pmk-wrap")
+	ad = append(ad, ephemeralPublicKey...)
+	return append(ad, recipientPublicKey...)
+}
+
+// EncryptENV encrypts data into an envelope without associated data. The
+// nonce is returned too, for callers that store it separately.
 func EncryptENV(pmk []byte, data []byte) ([]byte, []byte, error) {
-	block, err := aes.NewCipher(pmk)
+	envelope, err := SealEnvelope(pmk, data, nil)
 	if err != nil {
 		return nil, nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	rand.Read(nonce)
-
-	return gcm.Seal(nil, nonce, data, nil), nonce, nil
+	return envelope, EnvelopeNonce(envelope), nil
 }
 
+// DecryptENV decrypts data written by EncryptENV, or a legacy AES-GCM
+// ciphertext.
 func DecryptENV(pmk []byte, encryptedData []byte, nonce []byte) ([]byte, error) {
+	data, _, err := openBlob(pmk, encryptedData, nonce, nil)
+	return data, err
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
 
-	block, err := aes.NewCipher(pmk)
+// PublicKeyFromPrivate returns the X25519 public key of a private key.
+func PublicKeyFromPrivate(privateKeyBytes []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	return priv.PublicKey().Bytes(), nil
+}
+
+// EnvAssociatedData binds an environment ciphertext to where it belongs, so
+// the server cannot serve one environment or version in place of another.
+func EnvAssociatedData(projectId uuid.UUID, envName string, version int32) []byte {
+	ad := []byte("envcrypt-env-v2")
+	ad = append(ad, projectId[:]...)
+	ad = binary.BigEndian.AppendUint32(ad, uint32(len(envName)))
+	ad = append(ad, envName...)
+	ad = binary.BigEndian.AppendUint32(ad, uint32(version))
+	return ad
+}
+
+// SealEnv encrypts environment data into an envelope, authenticating ad.
+func SealEnv(pmk, data, ad []byte) ([]byte, []byte, error) {
+	envelope, err := SealEnvelope(pmk, data, ad)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return envelope, EnvelopeNonce(envelope), nil
+}
+
+// EnvFormat is the format an environment ciphertext was stored in.
+type EnvFormat int
 
-	return gcm.Open(nil, nonce, encryptedData, nil)
+const (
+	// EnvFormatUnbound is raw AES-256-GCM without associated data, which
+	// does not tie a ciphertext to its environment or version.
+	EnvFormatUnbound EnvFormat = iota
+	// EnvFormatEnvelope is the current format, bound to ad.
+	EnvFormatEnvelope
+)
+
+// Bound reports whether ciphertexts in the format are tied to ad.
+func (f EnvFormat) Bound() bool {
+	return f == EnvFormatEnvelope
 }
 
-func zero(b []byte) {
-	for i := range b {
-		b[i] = 0
+// OpenEnv decrypts environment data written by SealEnv, checking ad. Legacy
+// ciphertexts still decrypt; format tells which one the ciphertext used.
+func OpenEnv(pmk, cipherText, nonce, ad []byte) (data []byte, format EnvFormat, err error) {
+	if IsEnvelope(cipherText) {
+		data, err := OpenEnvelope(pmk, cipherText, ad)
+		if err == nil {
+			return data, EnvFormatEnvelope, nil
+		}
+		// A legacy ciphertext can start with the magic by chance.
+	}
+
+	data, err = openLegacy(pmk, cipherText, nonce, nil)
+	if err != nil {
+		return nil, EnvFormatUnbound, err
 	}
+	return data, EnvFormatUnbound, nil
 }
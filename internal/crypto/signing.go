@@ -0,0 +1,96 @@
+package cryptoutils
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/envcrypts/envcrypt-cli/internal/config"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/hkdf"
+)
+
+const versionSignatureDomain = "envcrypt-version-signature-v1"
+
+// DeriveSigningKey derives the user's Ed25519 signing key from their X25519
+// private key, so it needs no storage of its own and is covered by the same
+// keystore entry and recovery kit.
+func DeriveSigningKey(privateKey []byte) (ed25519.PrivateKey, error) {
+	if len(privateKey) != 32 {
+		return nil, errors.New("invalid private key length")
+	}
+
+	h := hkdf.New(sha256.New, privateKey, nil, []byte("envcrypt-signing-key"))
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(h, seed); err != nil {
+		return nil, err
+	}
+	defer zero(seed)
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// SigningPublicKey returns the Ed25519 public key derived from privateKey.
+func SigningPublicKey(privateKey []byte) ([]byte, error) {
+	key, err := DeriveSigningKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+// VersionSigningMessage builds the message signed for an environment
+// version. It covers the project, environment, metadata and a digest of the
+// plaintext, so re-encrypting a version under a new key keeps it valid.
+func VersionSigningMessage(projectId uuid.UUID, envName string, metadata config.Metadata, data []byte) []byte {
+	digest := sha256.Sum256(data)
+
+	var msg []byte
+	appendField := func(b []byte) {
+		msg = binary.BigEndian.AppendUint32(msg, uint32(len(b)))
+		msg = append(msg, b...)
+	}
+
+	appendField([]byte(versionSignatureDomain))
+	appendField(projectId[:])
+	appendField([]byte(envName))
+	appendField([]byte(metadata.Type))
+	appendField([]byte(metadata.Message))
+	appendField([]byte(metadata.Author))
+	msg = binary.BigEndian.AppendUint64(msg, uint64(metadata.CreatedAt.Unix()))
+	appendField(digest[:])
+	// Versions signed before history was chained have no PrevHash.
+	if len(metadata.PrevHash) > 0 {
+		appendField(metadata.PrevHash)
+	}
+
+	return msg
+}
+
+// VersionHash identifies a version in the hash chain of its environment.
+// Like the signature it covers content and metadata rather than ciphertext,
+// so key rotation does not break the chain.
+func VersionHash(projectId uuid.UUID, envName string, metadata config.Metadata, data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("envcrypt-version-hash-v1"))
+	h.Write(VersionSigningMessage(projectId, envName, metadata, data))
+	return h.Sum(nil)
+}
+
+func SignMessage(privateKey []byte, msg []byte) ([]byte, error) {
+	key, err := DeriveSigningKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(key, msg), nil
+}
+
+func VerifyMessage(publicKey, msg, signature []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(publicKey, msg, signature)
+}
@@ -0,0 +1,506 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"go.yaml.in/yaml/v3"
+)
+
+// InputFormats lists the formats Decode accepts. Shell covers `export`
+// lines and the output of `heroku config --shell`.
+var InputFormats = []Format{Dotenv, JSON, YAML, Shell}
+
+// DetectFormat guesses the input format of a file from its extension,
+// falling back to dotenv.
+func DetectFormat(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".yaml", ".yml":
+		return YAML
+	case ".sh":
+		return Shell
+	default:
+		return Dotenv
+	}
+}
+
+// ParseError reports where in the input a file could not be parsed. Line
+// and Column are 1-based; zero means the position is unknown.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	default:
+		return e.Msg
+	}
+}
+
+// errorAt builds a ParseError for a byte offset into data.
+func errorAt(data []byte, offset int, format string, args ...any) *ParseError {
+	if offset > len(data) {
+		offset = len(data)
+	}
+
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	column := utf8.RuneCount(data[lineStart:offset]) + 1
+
+	return &ParseError{Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Decode parses data in the given format into a flat environment map.
+// Nested JSON and YAML objects are flattened by joining keys with "_".
+func Decode(f Format, data []byte) (map[string]string, error) {
+	switch f {
+	case Dotenv:
+		return decodeDotenv(data)
+	case JSON:
+		return decodeJSON(data)
+	case YAML:
+		return decodeYAML(data)
+	case Shell:
+		return decodeShell(data)
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", f)
+	}
+}
+
+func decodeDotenv(data []byte) (map[string]string, error) {
+	env, err := cryptoutils.ParseEnv(data)
+	if err == nil {
+		return env, nil
+	}
+
+	if perr := locateDotenvError(data); perr != nil {
+		return nil, perr
+	}
+	return nil, err
+}
+
+// locateDotenvError walks the file with the same rules as godotenv to find
+// the position of the statement godotenv rejected, since its errors carry
+// no line information.
+func locateDotenvError(data []byte) *ParseError {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	i := 0
+
+	for i < len(data) {
+		// Skip blank space and comment lines
+		for i < len(data) && unicode.IsSpace(rune(data[i])) {
+			i++
+		}
+		if i >= len(data) {
+			return nil
+		}
+		if data[i] == '#' {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		if bytes.HasPrefix(data[i:], []byte("export")) && i+6 < len(data) && isBlank(data[i+6]) {
+			i += 6
+			for i < len(data) && isBlank(data[i]) {
+				i++
+			}
+		}
+
+		// Key
+		keyStart := i
+		for {
+			if i >= len(data) {
+				return errorAt(data, keyStart, "expected '=' after variable name")
+			}
+			c := data[i]
+			if c == '=' || c == ':' {
+				i++
+				break
+			}
+			if c == '\n' {
+				return errorAt(data, keyStart, "expected '=' after variable name")
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			if !isBlank(c) && r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+				return errorAt(data, i, "unexpected character %q in variable name", string(r))
+			}
+			i += size
+		}
+
+		for i < len(data) && isBlank(data[i]) {
+			i++
+		}
+
+		// Value
+		if i < len(data) && (data[i] == '"' || data[i] == '\'') {
+			quote := data[i]
+			start := i
+			i++
+			for i < len(data) && (data[i] != quote || data[i-1] == '\\') {
+				i++
+			}
+			if i >= len(data) {
+				return errorAt(data, start, "unterminated quoted value")
+			}
+			i++
+		}
+
+		for i < len(data) && data[i] != '\n' {
+			i++
+		}
+	}
+
+	return nil
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\v' || c == '\f' || c == '\r'
+}
+
+func decodeJSON(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(data, err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, &ParseError{Line: 1, Column: 1, Msg: "expected a JSON object at the top level"}
+	}
+
+	env := map[string]string{}
+	if err := flattenJSONObject(dec, data, env, ""); err != nil {
+		return nil, jsonError(data, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errorAt(data, int(dec.InputOffset()), "unexpected data after top-level object")
+	}
+	return env, nil
+}
+
+// jsonError adds a position to errors from the JSON decoder.
+func jsonError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return errorAt(data, int(syntaxErr.Offset)-1, "%s", syntaxErr.Error())
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errorAt(data, len(data), "unexpected end of JSON input")
+	}
+	return err
+}
+
+// flattenJSONObject reads the members of an object whose opening brace was
+// already consumed, including the closing brace.
+func flattenJSONObject(dec *json.Decoder, data []byte, env map[string]string, prefix string) error {
+	for dec.More() {
+		before := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		// The key starts at the first quote after the previous token.
+		keyOffset := before + bytes.IndexByte(data[before:], '"')
+		if err := flattenJSONValue(dec, data, env, joinKey(prefix, key), keyOffset); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+func flattenJSONValue(dec *json.Decoder, data []byte, env map[string]string, key string, keyOffset int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	var value string
+	switch val := tok.(type) {
+	case json.Delim:
+		if val == '{' {
+			return flattenJSONObject(dec, data, env, key)
+		}
+		for i := 0; dec.More(); i++ {
+			if err := flattenJSONValue(dec, data, env, joinKey(key, strconv.Itoa(i)), keyOffset); err != nil {
+				return err
+			}
+		}
+		_, err := dec.Token()
+		return err
+	case nil:
+		value = ""
+	case string:
+		value = val
+	case json.Number:
+		value = val.String()
+	case bool:
+		value = strconv.FormatBool(val)
+	default:
+		return errorAt(data, keyOffset, "unsupported value for key %q", key)
+	}
+
+	if !isEnvName(key) {
+		return errorAt(data, keyOffset, "%q is not a valid variable name after flattening", key)
+	}
+	if _, exists := env[key]; exists {
+		return errorAt(data, keyOffset, "key %q is defined more than once after flattening", key)
+	}
+	env[key] = value
+	return nil
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isEnvName reports whether key can be used as an environment variable.
+func isEnvName(key string) bool {
+	return envNamePattern.MatchString(key)
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func decodeYAML(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		msg := strings.TrimPrefix(err.Error(), "yaml: ")
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			msg = strings.TrimPrefix(strings.TrimPrefix(msg, m[0]), ": ")
+			return nil, &ParseError{Line: line, Msg: msg}
+		}
+		return nil, &ParseError{Msg: msg}
+	}
+
+	env := map[string]string{}
+	if len(doc.Content) == 0 {
+		return env, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &ParseError{Line: root.Line, Column: root.Column, Msg: "expected a mapping at the top level"}
+	}
+
+	if err := flattenYAML(env, "", root); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func flattenYAML(env map[string]string, prefix string, node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Kind != yaml.ScalarNode {
+				return &ParseError{Line: keyNode.Line, Column: keyNode.Column, Msg: "keys must be scalars"}
+			}
+
+			key := joinKey(prefix, keyNode.Value)
+			if keyNode.Tag == "!!merge" {
+				key = prefix
+			}
+			if err := flattenYAML(env, key, valueNode); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := flattenYAML(env, joinKey(prefix, strconv.Itoa(i)), child); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case yaml.ScalarNode:
+		if !isEnvName(prefix) {
+			return &ParseError{Line: node.Line, Column: node.Column, Msg: fmt.Sprintf("%q is not a valid variable name after flattening", prefix)}
+		}
+		if _, exists := env[prefix]; exists {
+			return &ParseError{Line: node.Line, Column: node.Column, Msg: fmt.Sprintf("key %q is defined more than once after flattening", prefix)}
+		}
+		if node.Tag == "!!null" {
+			env[prefix] = ""
+		} else {
+			env[prefix] = node.Value
+		}
+		return nil
+	}
+
+	return &ParseError{Line: node.Line, Column: node.Column, Msg: "unsupported YAML node"}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// decodeShell parses `KEY=value` lines with POSIX shell quoting, optionally
+// prefixed with `export`, as produced by `heroku config --shell`. Values are
+// taken literally; no variable expansion is performed.
+func decodeShell(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+	i := 0
+
+	for i < len(data) {
+		for i < len(data) && (isBlank(data[i]) || data[i] == '\n') {
+			i++
+		}
+		if i >= len(data) {
+			break
+		}
+		if data[i] == '#' {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		if bytes.HasPrefix(data[i:], []byte("export")) && i+6 < len(data) && isBlank(data[i+6]) {
+			i += 6
+			for i < len(data) && isBlank(data[i]) {
+				i++
+			}
+		}
+
+		keyStart := i
+		for i < len(data) && (data[i] == '_' || isAlnum(data[i])) {
+			i++
+		}
+		if i == keyStart || (data[keyStart] >= '0' && data[keyStart] <= '9') {
+			return nil, errorAt(data, keyStart, "expected a variable name")
+		}
+		key := string(data[keyStart:i])
+
+		if i >= len(data) || data[i] != '=' {
+			return nil, errorAt(data, i, "expected '=' after %s", key)
+		}
+		i++
+
+		value, next, err := readShellWord(data, i)
+		if err != nil {
+			return nil, err
+		}
+		i = next
+
+		for i < len(data) && isBlank(data[i]) {
+			i++
+		}
+		if i < len(data) && data[i] == '#' {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		}
+		if i < len(data) && data[i] != '\n' && data[i] != ';' {
+			return nil, errorAt(data, i, "unexpected text after value of %s", key)
+		}
+
+		env[key] = value
+		if i < len(data) && data[i] == ';' {
+			i++
+		}
+	}
+
+	return env, nil
+}
+
+// readShellWord reads one shell word starting at i and returns its unquoted
+// value and the offset just after it.
+func readShellWord(data []byte, i int) (string, int, error) {
+	var b strings.Builder
+
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case isBlank(c) || c == '\n' || c == ';':
+			return b.String(), i, nil
+
+		case c == '\'':
+			start := i
+			end := bytes.IndexByte(data[i+1:], '\'')
+			if end < 0 {
+				return "", 0, errorAt(data, start, "unterminated single-quoted value")
+			}
+			b.Write(data[i+1 : i+1+end])
+			i += end + 2
+
+		case c == '"':
+			start := i
+			i++
+			for {
+				if i >= len(data) {
+					return "", 0, errorAt(data, start, "unterminated double-quoted value")
+				}
+				c = data[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(data) {
+					switch next := data[i+1]; next {
+					case '"', '\\', '$', '`':
+						b.WriteByte(next)
+						i += 2
+						continue
+					case '\n':
+						i += 2
+						continue
+					}
+				}
+				b.WriteByte(c)
+				i++
+			}
+
+		case c == '\\':
+			if i+1 >= len(data) {
+				return "", 0, errorAt(data, i, "trailing backslash")
+			}
+			if data[i+1] != '\n' {
+				b.WriteByte(data[i+1])
+			}
+			i += 2
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), i, nil
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
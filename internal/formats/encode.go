@@ -0,0 +1,167 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cryptoutils "github.com/envcrypts/envcrypt-cli/internal/crypto"
+	"go.yaml.in/yaml/v3"
+)
+
+type EncodeOptions struct {
+	// SecretName names the generated Kubernetes Secret.
+	SecretName string
+}
+
+// Encode renders env in the given format. Every encoder quotes values so
+// that reading the output back yields exactly the same map, or returns an
+// error when the format cannot represent a value.
+func Encode(f Format, env map[string]string, opts EncodeOptions) ([]byte, error) {
+	switch f {
+	case Dotenv:
+		return cryptoutils.EncodeEnv(env)
+	case JSON:
+		return encodeJSON(env)
+	case YAML:
+		return encodeYAML(env)
+	case Shell:
+		return encodeShell(env), nil
+	case Docker:
+		return encodeDocker(env)
+	case Systemd:
+		return encodeSystemd(env), nil
+	case Kubernetes:
+		return encodeKubernetesSecret(env, opts.SecretName)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", f)
+	}
+}
+
+func encodeJSON(env map[string]string) ([]byte, error) {
+	if env == nil {
+		env = map[string]string{}
+	}
+
+	out, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func encodeYAML(env map[string]string) ([]byte, error) {
+	if len(env) == 0 {
+		return []byte("{}\n"), nil
+	}
+	return marshalYAML(env)
+}
+
+func marshalYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeShell writes POSIX `export` lines. Single quotes keep every byte
+// literal, including newlines; embedded single quotes are closed, escaped
+// and reopened.
+func encodeShell(env map[string]string) []byte {
+	var b strings.Builder
+	for _, k := range sortedKeys(env) {
+		b.WriteString("export ")
+		b.WriteString(k)
+		b.WriteString("='")
+		b.WriteString(strings.ReplaceAll(env[k], "'", `'\''`))
+		b.WriteString("'\n")
+	}
+	return []byte(b.String())
+}
+
+// encodeDocker writes the format read by `docker run --env-file`, which
+// takes values literally and has no quoting or multi-line support.
+func encodeDocker(env map[string]string) ([]byte, error) {
+	var b strings.Builder
+	for _, k := range sortedKeys(env) {
+		v := env[k]
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("%s contains a newline, which docker env files cannot represent", k)
+		}
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(v)
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+var systemdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+
+// encodeSystemd writes a systemd EnvironmentFile. Inside double quotes
+// systemd keeps newlines and unescapes \\, \", \` and \$.
+func encodeSystemd(env map[string]string) []byte {
+	var b strings.Builder
+	for _, k := range sortedKeys(env) {
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(systemdEscaper.Replace(env[k]))
+		b.WriteString("\"\n")
+	}
+	return []byte(b.String())
+}
+
+type kubernetesSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   kubernetesMeta    `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type kubernetesMeta struct {
+	Name string `yaml:"name"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// KubernetesName turns s into a valid DNS-1123 resource name.
+func KubernetesName(s string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-")
+	}
+	return name
+}
+
+// encodeKubernetesSecret base64 encodes every value under `data`, so any
+// byte sequence survives the round trip.
+func encodeKubernetesSecret(env map[string]string, name string) ([]byte, error) {
+	name = KubernetesName(name)
+	if name == "" {
+		return nil, fmt.Errorf("a secret name is required for the %s format", Kubernetes)
+	}
+
+	data := make(map[string]string, len(env))
+	for k, v := range env {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	return marshalYAML(kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   kubernetesMeta{Name: name},
+		Type:       "Opaque",
+		Data:       data,
+	})
+}
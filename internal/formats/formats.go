@@ -0,0 +1,68 @@
+// Package formats converts decrypted environments to and from the file
+// formats other tools expect.
+package formats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Format string
+
+const (
+	Dotenv     Format = "dotenv"
+	JSON       Format = "json"
+	YAML       Format = "yaml"
+	Shell      Format = "shell"
+	Docker     Format = "docker"
+	Systemd    Format = "systemd"
+	Kubernetes Format = "k8s"
+)
+
+// OutputFormats lists the formats Encode accepts.
+var OutputFormats = []Format{Dotenv, JSON, YAML, Shell, Docker, Systemd, Kubernetes}
+
+// ParseFormat validates a format name given on the command line.
+func ParseFormat(name string, allowed []Format) (Format, error) {
+	for _, f := range allowed {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+
+	names := make([]string, len(allowed))
+	for i, f := range allowed {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown format %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// DefaultFileName is the file pull writes to when no path is given.
+func (f Format) DefaultFileName() string {
+	switch f {
+	case JSON:
+		return "env.json"
+	case YAML:
+		return "env.yaml"
+	case Shell:
+		return "env.sh"
+	case Docker:
+		return "docker.env"
+	case Systemd:
+		return "env.conf"
+	case Kubernetes:
+		return "secret.yaml"
+	default:
+		return ".env"
+	}
+}
+
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -26,9 +26,7 @@ import (
 	"os"
 
 	"github.com/envcrypts/envcrypt-cli/cmd"
-	"github.com/envcrypts/envcrypt-cli/internal/app"
 	"github.com/envcrypts/envcrypt-cli/internal/config"
-	"github.com/spf13/viper"
 )
 
 func main() {
@@ -38,12 +36,5 @@ func main() {
 		os.Exit(1)
 	}
 
-	serverUrl := viper.GetString("api.base_url")
-	if serverUrl == "" {
-		serverUrl = "http://localhost:8081"
-	}
-
-
-	a := app.NewApp(serverUrl)
-	cmd.Execute(a)
+	cmd.Execute()
 }